@@ -0,0 +1,230 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/activity"
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/logger"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// activityLog is the default logger for the GraphQL Client Activity
+var activityLog = logger.GetLogger("activity-flogo-graphqlclient")
+
+const (
+	ivEndpoint       = "endpoint"
+	ivQuery          = "query"
+	ivVariables      = "variables"
+	ivOperationName  = "operationName"
+	ivHeaders        = "headers"
+	ivTimeout        = "timeout"
+	ivRetries        = "retries"
+	ivRetryDelay     = "retryDelay"
+	ivRetryMutations = "retryMutations"
+	ivValidate       = "validate"
+
+	ovData   = "data"
+	ovErrors = "errors"
+
+	defaultTimeout    = 30 * time.Second
+	defaultRetryDelay = time.Second
+)
+
+// GraphQLClientActivity sends a query to a GraphQL endpoint
+type GraphQLClientActivity struct {
+	metadata *activity.Metadata
+}
+
+// NewActivity creates a new activity
+func NewActivity(metadata *activity.Metadata) activity.Activity {
+	return &GraphQLClientActivity{metadata: metadata}
+}
+
+// Metadata implements activity.Activity.Metadata
+func (a *GraphQLClientActivity) Metadata() *activity.Metadata {
+	return a.metadata
+}
+
+// graphQLRequest is the body of a GraphQL over HTTP POST request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// graphQLResponse is the body of a GraphQL response
+type graphQLResponse struct {
+	Data   interface{}   `json:"data"`
+	Errors []interface{} `json:"errors"`
+}
+
+// Eval implements activity.Activity.Eval
+func (a *GraphQLClientActivity) Eval(ctx activity.Context) (done bool, err error) {
+	endpoint, _ := ctx.GetInput(ivEndpoint).(string)
+	if endpoint == "" {
+		return false, fmt.Errorf("an endpoint must be supplied")
+	}
+
+	query, _ := ctx.GetInput(ivQuery).(string)
+	if query == "" {
+		return false, fmt.Errorf("a query must be supplied")
+	}
+
+	variables, err := data.CoerceToObject(ctx.GetInput(ivVariables))
+	if err != nil {
+		return false, fmt.Errorf("invalid variables: %v", err)
+	}
+
+	operationName, _ := ctx.GetInput(ivOperationName).(string)
+
+	headers := map[string]string{}
+	if val := ctx.GetInput(ivHeaders); val != nil {
+		if headers, err = data.CoerceToParams(val); err != nil {
+			return false, fmt.Errorf("invalid headers: %v", err)
+		}
+	}
+
+	timeout, err := durationInput(ctx, ivTimeout, defaultTimeout)
+	if err != nil {
+		return false, err
+	}
+
+	retryDelay, err := durationInput(ctx, ivRetryDelay, defaultRetryDelay)
+	if err != nil {
+		return false, err
+	}
+
+	retries, err := data.CoerceToInteger(ctx.GetInput(ivRetries))
+	if err != nil {
+		return false, fmt.Errorf("invalid retries: %v", err)
+	}
+
+	// A mutation may have been applied by an attempt which failed, it is only retried when explicitly allowed
+	if retryMutations, _ := data.CoerceToBoolean(ctx.GetInput(ivRetryMutations)); !retryMutations && !isQuery(query, operationName) {
+		retries = 0
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	if validate, _ := data.CoerceToBoolean(ctx.GetInput(ivValidate)); validate {
+		if err := validateQuery(client, endpoint, headers, query); err != nil {
+			return false, err
+		}
+	}
+
+	body, err := json.Marshal(&graphQLRequest{Query: query, Variables: variables, OperationName: operationName})
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := post(client, endpoint, headers, body, retries, retryDelay)
+	if err != nil {
+		return false, err
+	}
+
+	activityLog.Debugf("Received %d errors from '%s'", len(resp.Errors), endpoint)
+
+	gqlErrors := resp.Errors
+	if gqlErrors == nil {
+		gqlErrors = []interface{}{}
+	}
+
+	ctx.SetOutput(ovData, resp.Data)
+	ctx.SetOutput(ovErrors, gqlErrors)
+
+	return true, nil
+}
+
+// durationInput reads an input holding a duration such as 10s, def is returned when it is not set
+func durationInput(ctx activity.Context, name string, def time.Duration) (time.Duration, error) {
+	val, _ := ctx.GetInput(name).(string)
+	if val == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s '%s'", name, val)
+	}
+
+	return d, nil
+}
+
+// isQuery reports whether the operation to execute is a query, the operation is unknown when the query does not parse
+func isQuery(query, operationName string) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return false
+	}
+
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok {
+			if operationName == "" || (op.Name != nil && op.Name.Value == operationName) {
+				return op.Operation == ast.OperationTypeQuery
+			}
+		}
+	}
+
+	return false
+}
+
+// post sends the request, retrying on connection errors and on 429 & 5xx statuses. The delay between attempts
+// doubles after each retry.
+func post(client *http.Client, endpoint string, headers map[string]string, body []byte, retries int, retryDelay time.Duration) (*graphQLResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, retry, err := postOnce(client, endpoint, headers, body)
+		if err == nil || !retry || attempt >= retries {
+			return resp, err
+		}
+
+		activityLog.Warnf("Attempt %d to query '%s' failed, retrying in %v: %v", attempt+1, endpoint, retryDelay, err)
+		time.Sleep(retryDelay)
+		retryDelay *= 2
+	}
+}
+
+// postOnce sends the request once, it reports whether a failed request may be retried
+func postOnce(client *http.Client, endpoint string, headers map[string]string, body []byte) (*graphQLResponse, bool, error) {
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json;q=0.9")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	httpResp, err := client.Do(req)
+	if err != nil {
+		return nil, true, fmt.Errorf("unable to query '%s': %v", endpoint, err)
+	}
+	defer httpResp.Body.Close()
+
+	content, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("unable to read the response of '%s': %v", endpoint, err)
+	}
+
+	retry := httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode >= http.StatusInternalServerError
+
+	// A GraphQL response is returned as is whatever the status, e.g. with errors and a 400 status for invalid queries
+	resp := &graphQLResponse{}
+	if err := json.Unmarshal(content, resp); err != nil || (resp.Data == nil && resp.Errors == nil) {
+		return nil, retry, fmt.Errorf("'%s' replied with status %d: %s", endpoint, httpResp.StatusCode, bytes.TrimSpace(content))
+	}
+
+	if resp.Data == nil && retry {
+		return nil, true, fmt.Errorf("'%s' replied with status %d: %v", endpoint, httpResp.StatusCode, resp.Errors)
+	}
+
+	return resp, false, nil
+}
@@ -0,0 +1,362 @@
+package graphqlclient
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-contrib/action/flow/test"
+	"github.com/TIBCOSoftware/flogo-lib/core/activity"
+	gql "github.com/graphql-go/graphql"
+	"github.com/mellistibco/flogo-activities/triggers/graphql/graphqltest"
+)
+
+var activityMetadata *activity.Metadata
+
+func getActivityMetadata() *activity.Metadata {
+
+	if activityMetadata == nil {
+		jsonMetadataBytes, err := ioutil.ReadFile("activity.json")
+		if err != nil {
+			panic("No Json Metadata found for activity.json path")
+		}
+
+		activityMetadata = activity.NewMetadata(string(jsonMetadataBytes))
+	}
+
+	return activityMetadata
+}
+
+// startServer starts a GraphQL trigger serving a user query, and returns its endpoint
+func startServer(t *testing.T) (string, func()) {
+
+	settings := map[string]interface{}{
+		"path": "/graphql",
+		"types": []interface{}{
+			map[string]interface{}{
+				"Name": "user",
+				"Fields": map[string]interface{}{
+					"id":   map[string]interface{}{"Type": "graphql.String"},
+					"name": map[string]interface{}{"Type": "graphql.String"},
+				},
+			},
+		},
+		"schema": map[string]interface{}{
+			"Query": map[string]interface{}{
+				"Name": "Query",
+				"Fields": map[string]interface{}{
+					"user": map[string]interface{}{
+						"Type": "user",
+						"Args": map[string]interface{}{"id": map[string]interface{}{"Type": "graphql.String"}},
+					},
+				},
+			},
+		},
+	}
+
+	server, err := graphqltest.NewServer(settings, graphqltest.NewHandler("user", map[string]interface{}{"id": "1", "name": "Matt"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server.URL + server.Path, server.Close
+}
+
+func TestCreate(t *testing.T) {
+
+	act := NewActivity(getActivityMetadata())
+
+	if act == nil {
+		t.Error("Activity Not Created")
+		t.Fail()
+		return
+	}
+}
+
+func TestEval(t *testing.T) {
+
+	endpoint, stop := startServer(t)
+	defer stop()
+
+	act := NewActivity(getActivityMetadata())
+	tc := test.NewTestActivityContext(getActivityMetadata())
+
+	//setup attrs
+	tc.SetInput("endpoint", endpoint)
+	tc.SetInput("query", "query User($id: String) { user(id: $id) { name } }")
+	tc.SetInput("variables", map[string]interface{}{"id": "1"})
+	tc.SetInput("operationName", "User")
+	tc.SetInput("validate", true)
+
+	done, err := act.Eval(tc)
+	if !done {
+		t.Fatal(err)
+	}
+
+	//check result attr
+	result, _ := tc.GetOutput("data").(map[string]interface{})
+	user, _ := result["user"].(map[string]interface{})
+	if user["name"] != "Matt" {
+		t.Errorf("Unexpected data: %v", tc.GetOutput("data"))
+	}
+
+	if errs, _ := tc.GetOutput("errors").([]interface{}); len(errs) != 0 {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+
+	endpoint, stop := startServer(t)
+	defer stop()
+
+	act := NewActivity(getActivityMetadata())
+	tc := test.NewTestActivityContext(getActivityMetadata())
+
+	//setup attrs
+	tc.SetInput("endpoint", endpoint)
+	tc.SetInput("query", "{ user { age } }")
+
+	done, err := act.Eval(tc)
+	if !done {
+		t.Fatal(err)
+	}
+
+	//check result attr
+	if errs, _ := tc.GetOutput("errors").([]interface{}); len(errs) == 0 {
+		t.Error("Expected the errors of the server")
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	endpoint, stop := startServer(t)
+	defer stop()
+
+	act := NewActivity(getActivityMetadata())
+	tc := test.NewTestActivityContext(getActivityMetadata())
+
+	//setup attrs
+	tc.SetInput("endpoint", endpoint)
+	tc.SetInput("query", "{ user { name { first } } }")
+	tc.SetInput("validate", true)
+
+	if done, _ := act.Eval(tc); done {
+		t.Error("Expected the query to be rejected")
+	}
+}
+
+func TestRetry(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer server.Close()
+
+	act := NewActivity(getActivityMetadata())
+	tc := test.NewTestActivityContext(getActivityMetadata())
+
+	//setup attrs
+	tc.SetInput("endpoint", server.URL)
+	tc.SetInput("query", "{ ok }")
+	tc.SetInput("retries", 1)
+	tc.SetInput("retryDelay", "10ms")
+
+	done, err := act.Eval(tc)
+	if !done {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestRetryMutations(t *testing.T) {
+
+	tests := []struct {
+		query          string
+		retryMutations bool
+		attempts       int32
+	}{
+		{"{ ok }", false, 2},
+		{"mutation { ok }", false, 1},
+		{"mutation { ok }", true, 2},
+	}
+
+	for _, tt := range tests {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data":{"ok":true}}`))
+		}))
+
+		act := NewActivity(getActivityMetadata())
+		tc := test.NewTestActivityContext(getActivityMetadata())
+
+		//setup attrs
+		tc.SetInput("endpoint", server.URL)
+		tc.SetInput("query", tt.query)
+		tc.SetInput("retries", 1)
+		tc.SetInput("retryDelay", "10ms")
+		tc.SetInput("retryMutations", tt.retryMutations)
+
+		done, _ := act.Eval(tc)
+		server.Close()
+
+		if calls != tt.attempts || done != (tt.attempts == 2) {
+			t.Errorf("%s with retryMutations %v: got %d attempts, done %v", tt.query, tt.retryMutations, calls, done)
+		}
+	}
+}
+
+func TestIsQuery(t *testing.T) {
+
+	tests := []struct {
+		query         string
+		operationName string
+		want          bool
+	}{
+		{"{ user { name } }", "", true},
+		{"query User { user { name } }", "", true},
+		{"mutation { addUser }", "", false},
+		{"query User { user { name } } mutation Add { addUser }", "User", true},
+		{"query User { user { name } } mutation Add { addUser }", "Add", false},
+		{"{ user ", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := isQuery(tt.query, tt.operationName); got != tt.want {
+			t.Errorf("isQuery(%q, %q) = %v, want %v", tt.query, tt.operationName, got, tt.want)
+		}
+	}
+}
+
+func TestValidateHeaders(t *testing.T) {
+
+	newSchema := func(fields gql.Fields) gql.Schema {
+		schema, err := gql.NewSchema(gql.SchemaConfig{Query: gql.NewObject(gql.ObjectConfig{Name: "Query", Fields: fields})})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return schema
+	}
+
+	public := newSchema(gql.Fields{"name": &gql.Field{Type: gql.String}})
+	admin := newSchema(gql.Fields{"name": &gql.Field{Type: gql.String}, "secret": &gql.Field{Type: gql.String}})
+
+	// The schema served depends on the credentials of the client
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		schema := public
+		if r.Header.Get("Authorization") == "admin" {
+			schema = admin
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(gql.Do(gql.Params{Schema: schema, RequestString: req.Query}))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		headers map[string]string
+		valid   bool
+	}{
+		{map[string]string{"Authorization": "admin"}, true},
+		{nil, false},
+		{map[string]string{"authorization": "admin"}, true},
+	}
+
+	for _, tt := range tests {
+		act := NewActivity(getActivityMetadata())
+		tc := test.NewTestActivityContext(getActivityMetadata())
+
+		//setup attrs
+		tc.SetInput("endpoint", server.URL)
+		tc.SetInput("query", "{ secret }")
+		tc.SetInput("headers", tt.headers)
+		tc.SetInput("validate", true)
+
+		if done, err := act.Eval(tc); done != tt.valid {
+			t.Errorf("headers %v: got done %v, %v", tt.headers, done, err)
+		}
+	}
+}
+
+func TestValidateCache(t *testing.T) {
+
+	schema, err := gql.NewSchema(gql.SchemaConfig{Query: gql.NewObject(gql.ObjectConfig{
+		Name:   "Query",
+		Fields: gql.Fields{"name": &gql.Field{Type: gql.String}},
+	})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var introspections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(req.Query, "__schema") {
+			atomic.AddInt32(&introspections, 1)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(gql.Do(gql.Params{Schema: schema, RequestString: req.Query}))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		query          string
+		expire         bool
+		valid          bool
+		introspections int32
+	}{
+		{"{ name }", false, true, 1},
+		// Queries which don't validate are rejected by the cached schema
+		{"{ age }", false, false, 1},
+		{"{ age }", false, false, 1},
+		// The schema is introspected again once it has expired
+		{"{ age }", true, false, 2},
+		{"{ name }", false, true, 2},
+	}
+
+	for _, tt := range tests {
+		if tt.expire {
+			schemasMu.Lock()
+			schemas[schemaKey(server.URL, nil)].fetched = time.Now().Add(-schemaTTL - time.Second)
+			schemasMu.Unlock()
+		}
+
+		err := validateQuery(server.Client(), server.URL, nil, tt.query)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got %v, valid %v", tt.query, err, tt.valid)
+		}
+		if got := atomic.LoadInt32(&introspections); got != tt.introspections {
+			t.Errorf("%s: got %d introspections, want %d", tt.query, got, tt.introspections)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package graphqlclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) {
+        name
+        args { name type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+      }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// typeRef is a reference to a type, wrapped by lists and non-nulls
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// named returns the name of the type, unwrapping lists and non-nulls
+func (ref *typeRef) named() string {
+	for ref.OfType != nil {
+		ref = ref.OfType
+	}
+	return ref.Name
+}
+
+type introspectedArg struct {
+	Name         string   `json:"name"`
+	Type         *typeRef `json:"type"`
+	DefaultValue *string  `json:"defaultValue"`
+}
+
+type introspectedField struct {
+	Name string             `json:"name"`
+	Args []*introspectedArg `json:"args"`
+	Type *typeRef           `json:"type"`
+}
+
+type introspectedType struct {
+	Kind   string               `json:"kind"`
+	Name   string               `json:"name"`
+	Fields []*introspectedField `json:"fields"`
+}
+
+func (typ *introspectedType) field(name string) *introspectedField {
+	for _, f := range typ.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// introspectedSchema is the part of an introspected schema needed to validate queries
+type introspectedSchema struct {
+	QueryType        *typeRef            `json:"queryType"`
+	MutationType     *typeRef            `json:"mutationType"`
+	SubscriptionType *typeRef            `json:"subscriptionType"`
+	Types            []*introspectedType `json:"types"`
+
+	types map[string]*introspectedType
+}
+
+// schemaTTL is how long an introspected schema is cached before the endpoint is introspected again
+const schemaTTL = 5 * time.Minute
+
+// cachedSchema is an introspected schema along with the time it was fetched
+type cachedSchema struct {
+	schema  *introspectedSchema
+	fetched time.Time
+}
+
+var schemasMu sync.Mutex
+var schemas = map[string]*cachedSchema{}
+
+// schemaKey is the key of the schema of an endpoint in the cache. The headers are part of the key, as the schema
+// served to a client may depend on its credentials.
+func schemaKey(endpoint string, headers map[string]string) string {
+	fields := make([]string, 0, len(headers))
+	for k, v := range headers {
+		fields = append(fields, http.CanonicalHeaderKey(k)+": "+v)
+	}
+	sort.Strings(fields)
+
+	return endpoint + "\n" + strings.Join(fields, "\n")
+}
+
+// validateQuery validates the query against the schema of the endpoint, which is introspected once and cached for
+// the headers. The schema is introspected again once it is older than schemaTTL, in case the endpoint has been
+// updated.
+func validateQuery(client *http.Client, endpoint string, headers map[string]string, query string) error {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return fmt.Errorf("invalid query: %v", err)
+	}
+
+	key := schemaKey(endpoint, headers)
+
+	schemasMu.Lock()
+	cached := schemas[key]
+	schemasMu.Unlock()
+
+	if cached == nil || time.Since(cached.fetched) > schemaTTL {
+		schema, err := introspect(client, endpoint, headers)
+		if err != nil {
+			return err
+		}
+
+		cached = &cachedSchema{schema: schema, fetched: time.Now()}
+
+		schemasMu.Lock()
+		schemas[key] = cached
+		schemasMu.Unlock()
+	}
+
+	if err := cached.schema.validate(doc); err != nil {
+		return fmt.Errorf("invalid query: %v", err)
+	}
+
+	return nil
+}
+
+// introspect fetches the schema of the endpoint
+func introspect(client *http.Client, endpoint string, headers map[string]string) (*introspectedSchema, error) {
+	body, err := json.Marshal(&graphQLRequest{Query: introspectionQuery})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, _, err := postOnce(client, endpoint, headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to introspect the schema of '%s': %v", endpoint, err)
+	}
+
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unable to introspect the schema of '%s': %v", endpoint, resp.Errors)
+	}
+
+	// Decode the data again into the introspection types
+	content, err := json.Marshal(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &struct {
+		Schema *introspectedSchema `json:"__schema"`
+	}{}
+	if err := json.Unmarshal(content, result); err != nil || result.Schema == nil {
+		return nil, fmt.Errorf("invalid introspection result from '%s'", endpoint)
+	}
+
+	schema := result.Schema
+	schema.types = make(map[string]*introspectedType, len(schema.Types))
+	for _, typ := range schema.Types {
+		schema.types[typ.Name] = typ
+	}
+
+	return schema, nil
+}
+
+// validate checks that the fields and fragments of the query exist in the schema, that the arguments are known and
+// the required ones are supplied, and that only the fields of objects have selections
+func (s *introspectedSchema) validate(doc *ast.Document) error {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if def, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[def.Name.Value] = def
+		}
+	}
+
+	v := &validator{schema: s, fragments: fragments, visited: make(map[string]bool)}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		var root *typeRef
+		switch op.Operation {
+		case ast.OperationTypeQuery:
+			root = s.QueryType
+		case ast.OperationTypeMutation:
+			root = s.MutationType
+		case ast.OperationTypeSubscription:
+			root = s.SubscriptionType
+		}
+
+		if root == nil || s.types[root.Name] == nil {
+			return fmt.Errorf("the schema does not support %s operations", op.Operation)
+		}
+
+		if err := v.selectionSet(s.types[root.Name], op.SelectionSet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type validator struct {
+	schema    *introspectedSchema
+	fragments map[string]*ast.FragmentDefinition
+	visited   map[string]bool
+}
+
+func (v *validator) selectionSet(parent *introspectedType, set *ast.SelectionSet) error {
+	if set == nil {
+		return nil
+	}
+
+	for _, selection := range set.Selections {
+		var err error
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			err = v.field(parent, selection)
+		case *ast.InlineFragment:
+			typ := parent
+			if selection.TypeCondition != nil {
+				if typ, err = v.namedType(selection.TypeCondition.Name.Value); err != nil {
+					return err
+				}
+			}
+			err = v.selectionSet(typ, selection.SelectionSet)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			def, ok := v.fragments[name]
+			if !ok {
+				return fmt.Errorf("unknown fragment '%s'", name)
+			}
+			if v.visited[name] {
+				continue
+			}
+			v.visited[name] = true
+
+			var typ *introspectedType
+			if typ, err = v.namedType(def.TypeCondition.Name.Value); err != nil {
+				return err
+			}
+			err = v.selectionSet(typ, def.SelectionSet)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) namedType(name string) (*introspectedType, error) {
+	typ, ok := v.schema.types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type '%s'", name)
+	}
+	return typ, nil
+}
+
+func (v *validator) field(parent *introspectedType, field *ast.Field) error {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		// Meta fields such as __typename & __schema are served by every endpoint
+		return nil
+	}
+
+	def := parent.field(name)
+	if def == nil {
+		return fmt.Errorf("cannot query field '%s' on type '%s'", name, parent.Name)
+	}
+
+	supplied := make(map[string]bool, len(field.Arguments))
+	for _, arg := range field.Arguments {
+		supplied[arg.Name.Value] = true
+
+		known := false
+		for _, argDef := range def.Args {
+			known = known || argDef.Name == arg.Name.Value
+		}
+		if !known {
+			return fmt.Errorf("unknown argument '%s' on field '%s' of type '%s'", arg.Name.Value, name, parent.Name)
+		}
+	}
+
+	for _, argDef := range def.Args {
+		if argDef.Type.Kind == "NON_NULL" && argDef.DefaultValue == nil && !supplied[argDef.Name] {
+			return fmt.Errorf("field '%s' argument '%s' is required but not provided", name, argDef.Name)
+		}
+	}
+
+	typ, err := v.namedType(def.Type.named())
+	if err != nil {
+		return err
+	}
+
+	switch typ.Kind {
+	case "OBJECT", "INTERFACE", "UNION":
+		if field.SelectionSet == nil {
+			return fmt.Errorf("field '%s' of type '%s' must have a selection of subfields", name, typ.Name)
+		}
+	default:
+		if field.SelectionSet != nil {
+			return fmt.Errorf("field '%s' must not have a selection since type '%s' has no subfields", name, typ.Name)
+		}
+	}
+
+	return v.selectionSet(typ, field.SelectionSet)
+}
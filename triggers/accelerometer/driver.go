@@ -0,0 +1,138 @@
+package accelerometer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Driver reads the accelerations measured by a sensor
+type Driver interface {
+	// Init prepares the sensor for measurement
+	Init() error
+
+	// Read returns the next acceleration measured by the sensor, io.EOF when the sensor has no more samples
+	Read() (*Acceleration, error)
+
+	// Destroy releases the sensor
+	Destroy()
+}
+
+// DriverFactory creates a driver from the settings of the trigger
+type DriverFactory func(settings map[string]interface{}) (Driver, error)
+
+const defaultDriver = "adxl345"
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]DriverFactory{
+		"adxl345":   newAdxl345Driver,
+		"simulated": newSimulatedDriver,
+		"replay":    newReplayDriver,
+	}
+)
+
+// RegisterDriver makes a driver available to the driver setting of the trigger under the given name
+func RegisterDriver(name string, factory DriverFactory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	drivers[name] = factory
+}
+
+// NewDriver creates the driver registered under the given name, the adxl345 driver when the name is empty
+func NewDriver(name string, settings map[string]interface{}) (Driver, error) {
+	if name == "" {
+		name = defaultDriver
+	}
+
+	driversMu.RLock()
+	factory, ok := drivers[name]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown driver '%s', valid drivers are %s", name, strings.Join(driverNames(), ", "))
+	}
+
+	return factory(settings)
+}
+
+func driverNames() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newAdxl345Driver creates the driver of an ADXL345 on an I2C bus, at the address & bus settings, which default to
+// 0x53 on bus 1
+func newAdxl345Driver(settings map[string]interface{}) (Driver, error) {
+	address, err := intSetting(settings, "address", 0x53)
+	if err != nil {
+		return nil, err
+	}
+
+	bus, err := intSetting(settings, "bus", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	adxl, err := NewAdxl345(uint8(address), bus)
+	if err != nil {
+		return nil, err
+	}
+	return adxl, nil
+}
+
+// intSetting returns an integer setting, given as a number or a string such as "0x53"
+func intSetting(settings map[string]interface{}, name string, def int) (int, error) {
+	switch val := settings[name].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return val, nil
+	case float64:
+		return int(val), nil
+	case string:
+		if val == "" {
+			return def, nil
+		}
+		n, err := strconv.ParseInt(val, 0, 0)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s '%s'", name, val)
+		}
+		return int(n), nil
+	}
+
+	return 0, fmt.Errorf("invalid %s '%v'", name, settings[name])
+}
+
+// floatSetting returns a number setting, given as a number or a string
+func floatSetting(settings map[string]interface{}, name string, def float64) (float64, error) {
+	switch val := settings[name].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return float64(val), nil
+	case float64:
+		return val, nil
+	case string:
+		if val == "" {
+			return def, nil
+		}
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s '%s'", name, val)
+		}
+		return f, nil
+	}
+
+	return 0, fmt.Errorf("invalid %s '%v'", name, settings[name])
+}
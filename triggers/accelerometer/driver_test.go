@@ -0,0 +1,122 @@
+package accelerometer
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewDriver(t *testing.T) {
+	if _, err := NewDriver("unknown", nil); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+
+	if _, err := NewDriver("simulated", map[string]interface{}{"waveform": "square"}); err == nil {
+		t.Error("expected an error for an unknown waveform")
+	}
+
+	if _, err := NewDriver("replay", map[string]interface{}{}); err == nil {
+		t.Error("expected an error for a replay without file")
+	}
+}
+
+func TestSimulatedDriver(t *testing.T) {
+	for _, waveform := range []string{"sine", "step", "noise"} {
+		driver, err := NewDriver("simulated", map[string]interface{}{
+			"waveform":   waveform,
+			"amplitude":  500,
+			"frequency":  "10",
+			"sampleRate": 1000,
+			"seed":       1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := driver.Init(); err != nil {
+			t.Fatal(err)
+		}
+
+		var max float64
+		for i := 0; i < 100; i++ {
+			sample, err := driver.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			max = math.Max(max, math.Abs(sample.data[0]))
+		}
+		driver.Destroy()
+
+		if waveform != "noise" && math.Abs(max-500) > 1e-6 {
+			t.Errorf("waveform '%s' peaks at %v instead of the amplitude", waveform, max)
+		}
+		if max == 0 {
+			t.Errorf("waveform '%s' is flat", waveform)
+		}
+	}
+}
+
+func TestReplayDriver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accelerometer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"samples.csv":   "time,z,y,x\n0,1000,2,1\n10,1001,4,3\n",
+		"samples.jsonl": "{\"x\":1,\"y\":2,\"z\":1000}\n\n[3,4,1001]\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		driver, err := NewDriver("replay", map[string]interface{}{"file": path, "sampleRate": 0, "loop": true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := driver.Init(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := [][]float64{{1, 2, 1000}, {3, 4, 1001}, {1, 2, 1000}}
+		for _, want := range expected {
+			sample, err := driver.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for axis := range want {
+				if sample.data[axis] != want[axis] {
+					t.Errorf("%s: read %v instead of %v", name, sample.data, want)
+					break
+				}
+			}
+		}
+		driver.Destroy()
+	}
+
+	path := filepath.Join(dir, "samples.jsonl")
+	driver, err := NewDriver("replay", map[string]interface{}{"file": path, "sampleRate": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := driver.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Destroy()
+
+	for i := 0; i < 2; i++ {
+		if _, err := driver.Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := driver.Read(); err != io.EOF {
+		t.Errorf("expected io.EOF at the end of the file, got %v", err)
+	}
+}
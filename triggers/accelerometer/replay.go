@@ -0,0 +1,224 @@
+package accelerometer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The formats of the files read by the replay driver
+const (
+	replayFormatCSV       = "csv"
+	replayFormatJSONLines = "jsonl"
+)
+
+// replayDriver replays the samples recorded in a file, in mg, at the pace of the sample rate. The samples are rows of
+// a CSV file, with x, y & z columns, or JSON lines holding an object with x, y & z keys or an array of the 3 values.
+type replayDriver struct {
+	file       string
+	format     string
+	loop       bool
+	sampleRate float64 /* Hz */
+
+	samples []*Acceleration
+	next    int
+	ticker  *time.Ticker
+}
+
+func newReplayDriver(settings map[string]interface{}) (Driver, error) {
+	d := &replayDriver{}
+
+	d.file, _ = settings["file"].(string)
+	if d.file == "" {
+		return nil, fmt.Errorf("no file found for the replay driver")
+	}
+
+	d.format, _ = settings["format"].(string)
+	if d.format == "" {
+		d.format = replayFormatJSONLines
+		if strings.EqualFold(filepath.Ext(d.file), ".csv") {
+			d.format = replayFormatCSV
+		}
+	}
+	if d.format != replayFormatCSV && d.format != replayFormatJSONLines {
+		return nil, fmt.Errorf("invalid format '%s'", d.format)
+	}
+
+	switch loop := settings["loop"].(type) {
+	case bool:
+		d.loop = loop
+	case string:
+		d.loop, _ = strconv.ParseBool(loop)
+	}
+
+	var err error
+	if d.sampleRate, err = floatSetting(settings, "sampleRate", 100); err != nil {
+		return nil, err
+	}
+	if d.sampleRate < 0 {
+		return nil, fmt.Errorf("invalid sampleRate '%v'", d.sampleRate)
+	}
+
+	return d, nil
+}
+
+// Init reads the samples of the file
+func (d *replayDriver) Init() error {
+	f, err := os.Open(d.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if d.format == replayFormatCSV {
+		d.samples, err = readCSVSamples(f)
+	} else {
+		d.samples, err = readJSONSamples(f)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid samples file '%s': %v", d.file, err)
+	}
+
+	if len(d.samples) == 0 {
+		return fmt.Errorf("no samples found in file '%s'", d.file)
+	}
+
+	d.next = 0
+	if d.sampleRate > 0 {
+		d.ticker = time.NewTicker(time.Duration(float64(time.Second) / d.sampleRate))
+	}
+
+	return nil
+}
+
+func (d *replayDriver) Destroy() {
+	if d.ticker != nil {
+		d.ticker.Stop()
+	}
+}
+
+// Read returns the next sample of the file, it starts over at the end of the file when the driver loops, and returns
+// io.EOF otherwise
+func (d *replayDriver) Read() (*Acceleration, error) {
+	if d.next == len(d.samples) {
+		if !d.loop {
+			return nil, io.EOF
+		}
+		d.next = 0
+	}
+
+	if d.ticker != nil {
+		<-d.ticker.C
+	}
+
+	sample := d.samples[d.next]
+	d.next++
+
+	// The trigger scales the data it reads, so each read gets its own copy
+	return &Acceleration{data: append([]float64(nil), sample.data...)}, nil
+}
+
+// readCSVSamples reads the rows of a CSV file, whose first row may name the x, y & z columns
+func readCSVSamples(r io.Reader) ([]*Acceleration, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []int{0, 1, 2}
+	var samples []*Acceleration
+
+	for i, record := range records {
+		if i == 0 && len(record) > 0 {
+			if _, err := strconv.ParseFloat(record[0], 64); err != nil {
+				if columns, err = csvColumns(record); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		sample := &Acceleration{data: make([]float64, 3)}
+		for axis, column := range columns {
+			if column >= len(record) {
+				return nil, fmt.Errorf("line %d: missing column %d", i+1, column+1)
+			}
+			if sample.data[axis], err = strconv.ParseFloat(record[column], 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid value '%s'", i+1, record[column])
+			}
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
+
+// csvColumns returns the index of the x, y & z columns of a CSV header
+func csvColumns(header []string) ([]int, error) {
+	columns := []int{-1, -1, -1}
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "x":
+			columns[0] = i
+		case "y":
+			columns[1] = i
+		case "z":
+			columns[2] = i
+		}
+	}
+
+	for axis, column := range columns {
+		if column < 0 {
+			return nil, fmt.Errorf("no '%s' column found in header", "xyz"[axis:axis+1])
+		}
+	}
+
+	return columns, nil
+}
+
+// readJSONSamples reads JSON lines, each holding an object with x, y & z keys or an array of the 3 values
+func readJSONSamples(r io.Reader) ([]*Acceleration, error) {
+	var samples []*Acceleration
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		sample := &Acceleration{data: make([]float64, 3)}
+
+		if text[0] == '[' {
+			var values []float64
+			if err := json.Unmarshal(text, &values); err != nil || len(values) != 3 {
+				return nil, fmt.Errorf("line %d: expected an array of 3 numbers", line)
+			}
+			copy(sample.data, values)
+		} else {
+			var values struct {
+				X, Y, Z *float64
+			}
+			if err := json.Unmarshal(text, &values); err != nil || values.X == nil || values.Y == nil || values.Z == nil {
+				return nil, fmt.Errorf("line %d: expected an object with x, y & z numbers", line)
+			}
+			sample.data[0], sample.data[1], sample.data[2] = *values.X, *values.Y, *values.Z
+		}
+
+		samples = append(samples, sample)
+	}
+
+	return samples, scanner.Err()
+}
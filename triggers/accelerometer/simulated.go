@@ -0,0 +1,126 @@
+package accelerometer
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// The waveforms generated by the simulated driver
+const (
+	waveformNoise = "noise"
+	waveformSine  = "sine"
+	waveformStep  = "step"
+)
+
+// gravity is the acceleration measured on the z axis of a sensor lying flat, in mg
+const gravity = 1000.0
+
+// simulatedDriver generates the accelerations of a sensor lying flat and moved along a waveform, so that the trigger
+// can run without a device. The axes are a third of a period apart.
+type simulatedDriver struct {
+	waveform   string
+	amplitude  float64 /* mg */
+	frequency  float64 /* Hz */
+	noise      float64 /* mg */
+	sampleRate float64 /* Hz */
+
+	rand   *rand.Rand
+	sample int
+	ticker *time.Ticker
+}
+
+func newSimulatedDriver(settings map[string]interface{}) (Driver, error) {
+	d := &simulatedDriver{waveform: waveformSine}
+
+	if waveform, ok := settings["waveform"].(string); ok && waveform != "" {
+		if waveform != waveformNoise && waveform != waveformSine && waveform != waveformStep {
+			return nil, fmt.Errorf("invalid waveform '%s'", waveform)
+		}
+		d.waveform = waveform
+	}
+
+	var err error
+	if d.amplitude, err = floatSetting(settings, "amplitude", 1000); err != nil {
+		return nil, err
+	}
+	if d.frequency, err = floatSetting(settings, "frequency", 1); err != nil {
+		return nil, err
+	}
+	if d.frequency <= 0 {
+		return nil, fmt.Errorf("invalid frequency '%v'", d.frequency)
+	}
+	if d.noise, err = floatSetting(settings, "noise", 0); err != nil {
+		return nil, err
+	}
+	if d.sampleRate, err = floatSetting(settings, "sampleRate", 100); err != nil {
+		return nil, err
+	}
+	if d.sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sampleRate '%v'", d.sampleRate)
+	}
+
+	seed, err := intSetting(settings, "seed", 0)
+	if err != nil {
+		return nil, err
+	}
+	if seed == 0 {
+		seed = int(time.Now().UnixNano())
+	}
+	d.rand = rand.New(rand.NewSource(int64(seed)))
+
+	return d, nil
+}
+
+func (d *simulatedDriver) Init() error {
+	d.sample = 0
+	d.ticker = time.NewTicker(time.Duration(float64(time.Second) / d.sampleRate))
+	return nil
+}
+
+func (d *simulatedDriver) Destroy() {
+	if d.ticker != nil {
+		d.ticker.Stop()
+	}
+}
+
+// Read returns the next sample, at the pace of the sample rate
+func (d *simulatedDriver) Read() (*Acceleration, error) {
+	if d.ticker != nil {
+		<-d.ticker.C
+	}
+
+	return d.next(), nil
+}
+
+// next generates the next sample, the time of a sample being its index over the sample rate
+func (d *simulatedDriver) next() *Acceleration {
+	t := float64(d.sample) / d.sampleRate
+	d.sample++
+
+	ret := &Acceleration{
+		data: make([]float64, 3),
+	}
+	for axis := range ret.data {
+		ret.data[axis] = d.wave(t+float64(axis)/(3*d.frequency)) + d.noise*d.rand.NormFloat64()
+	}
+	ret.data[2] += gravity
+
+	return ret
+}
+
+// wave returns the value of the waveform at time t
+func (d *simulatedDriver) wave(t float64) float64 {
+	switch d.waveform {
+	case waveformNoise:
+		return d.amplitude * d.rand.NormFloat64()
+	case waveformStep:
+		if math.Mod(t*d.frequency, 1) < 0.5 {
+			return d.amplitude
+		}
+		return -d.amplitude
+	}
+
+	return d.amplitude * math.Sin(2*math.Pi*d.frequency*t)
+}
@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
-	"errors"
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/TIBCOSoftware/flogo-contrib/action/flow/support"
 	"github.com/TIBCOSoftware/flogo-lib/core/action"
@@ -121,20 +122,24 @@ func NewAdxl345(address uint8, device int) (*Adxl345, error) {
 	return &adxl, nil
 }
 
-func (adxl *Adxl345) Init() {
+// Init implements Driver.Init
+func (adxl *Adxl345) Init() error {
 	if err := adxl.checkDevID(); err != nil {
-		log.Fatalf(err.Error())
+		return err
 	}
 
 	adxl.setRegister(regDataFormat, dataFormatRange16g|dataFormatFullRes)
 	adxl.setRegister(regBWRate, bwRate400)
 	adxl.setRegister(regPowerCtl, powerCtlMeasure)
+	return nil
 }
 
+// Destroy implements Driver.Destroy
 func (adxl *Adxl345) Destroy() {
 }
 
-func (adxl *Adxl345) Read() *Acceleration {
+// Read implements Driver.Read
+func (adxl *Adxl345) Read() (*Acceleration, error) {
 	data := make([]byte, 6, 6)
 	var xReg int16
 	var yReg int16
@@ -155,7 +160,7 @@ func (adxl *Adxl345) Read() *Acceleration {
 	ret.data[1] = float64(yReg) * fullResolutionScaleFactor
 	ret.data[2] = float64(zReg) * fullResolutionScaleFactor
 
-	return ret
+	return ret, nil
 }
 
 func (adxl *Adxl345) checkDevID() error {
@@ -165,7 +170,7 @@ func (adxl *Adxl345) checkDevID() error {
 	adxl.bus.Read(data)
 
 	if data[0] != deviceID {
-		errors.New(fmt.Sprintf("ADXL345 at %x on bus %d returned wrong device id: %x\n", adxl.address, adxl.device, data[0]))
+		return fmt.Errorf("ADXL345 at %x on bus %d returned wrong device id: %x", adxl.address, adxl.device, data[0])
 	}
 
 	return nil
@@ -197,6 +202,8 @@ type MyTrigger struct {
 	metadata *trigger.Metadata
 	runner   action.Runner
 	config   *trigger.Config
+	done     chan struct{}
+	exited   chan struct{}
 }
 
 // Init implements trigger.Trigger.Init
@@ -213,23 +220,84 @@ var timeMutex sync.Mutex
 
 // Start implements trigger.Trigger.Start
 func (t *MyTrigger) Start() error {
-	go t.readData()
+	name, _ := t.config.Settings["driver"].(string)
+	driver, err := NewDriver(name, t.config.Settings)
+	if err != nil {
+		return fmt.Errorf("unable to create the driver of trigger '%s': %v", t.config.Id, err)
+	}
+
+	if err := driver.Init(); err != nil {
+		return fmt.Errorf("unable to initialize the driver of trigger '%s': %v", t.config.Id, err)
+	}
+
+	t.done = make(chan struct{})
+	t.exited = make(chan struct{})
+	go t.readData(driver, t.done, t.exited)
 
 	return nil
 }
 
-// Stop implements trigger.Trigger.Stop
+// Stop implements trigger.Trigger.Stop, it waits for the driver to be released
 func (t *MyTrigger) Stop() error {
-	// stop the trigger
+	if t.done != nil {
+		close(t.done)
+		<-t.exited
+		t.done = nil
+		t.exited = nil
+	}
 	return nil
 }
 
-func (t *MyTrigger) readData() {
-	adxl, _ := NewAdxl345(0x53, 1)
-	adxl.Init()
+const (
+	// readBackoff is the wait after a failed read, doubled after each consecutive failure up to maxReadBackoff
+	readBackoff    = 100 * time.Millisecond
+	maxReadBackoff = 5 * time.Second
+
+	// maxReadFailures is the number of consecutive failed reads after which the trigger stops reading
+	maxReadFailures = 10
+)
+
+func (t *MyTrigger) readData(driver Driver, done, exited chan struct{}) {
+	defer close(exited)
+	defer driver.Destroy()
+
+	failures := 0
+	backoff := readBackoff
 
 	for {
-		data := adxl.Read()
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		data, err := driver.Read()
+		if err == io.EOF {
+			log.Info("The driver has no more samples")
+			return
+		}
+		if err != nil {
+			failures++
+			if failures >= maxReadFailures {
+				log.Errorf("Trigger '%s' stopped reading after %d failed reads: %v", t.config.Id, failures, err)
+				return
+			}
+			log.Errorf("Unable to read the driver of trigger '%s', retrying in %v: %v", t.config.Id, backoff, err)
+
+			select {
+			case <-done:
+				return
+			case <-time.After(backoff):
+			}
+
+			if backoff *= 2; backoff > maxReadBackoff {
+				backoff = maxReadBackoff
+			}
+			continue
+		}
+
+		failures = 0
+		backoff = readBackoff
 
 		// Pass the data to the flow
 		handlers := t.config.Handlers
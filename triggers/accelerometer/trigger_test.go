@@ -3,8 +3,11 @@ package accelerometer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/TIBCOSoftware/flogo-lib/core/action"
 	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
@@ -56,3 +59,70 @@ func TestInit(t *testing.T) {
 
 	tgr.Init(runner)
 }
+
+// failingDriver is a sensor whose reads always fail, it signals each read on the reads channel
+type failingDriver struct {
+	reads     chan struct{}
+	destroyed int32
+}
+
+func (d *failingDriver) Init() error { return nil }
+
+func (d *failingDriver) Read() (*Acceleration, error) {
+	d.reads <- struct{}{}
+	return nil, errors.New("remote I/O error")
+}
+
+func (d *failingDriver) Destroy() { atomic.StoreInt32(&d.destroyed, 1) }
+
+func TestStopFailingDriver(t *testing.T) {
+	// The driver is registered under a name of its own, which is removed once done
+	driver := &failingDriver{reads: make(chan struct{}, maxReadFailures)}
+	name := "failing-" + t.Name()
+	RegisterDriver(name, func(settings map[string]interface{}) (Driver, error) {
+		return driver, nil
+	})
+	defer func() {
+		driversMu.Lock()
+		delete(drivers, name)
+		driversMu.Unlock()
+	}()
+
+	tgr := &MyTrigger{config: &trigger.Config{Id: "accelerometer", Settings: map[string]interface{}{"driver": name}}}
+	if err := tgr.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-driver.reads:
+	case <-time.After(time.Second):
+		t.Fatal("the driver was not read")
+	}
+
+	// The trigger backs off after the failed read, Stop interrupts the backoff
+	stopped := make(chan struct{})
+	go func() {
+		tgr.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not interrupt the backoff")
+	}
+
+	if atomic.LoadInt32(&driver.destroyed) != 1 {
+		t.Error("Stop returned before the driver was released")
+	}
+
+	// Reads made before Stop returned are drained, the trigger must not read once stopped
+	for len(driver.reads) > 0 {
+		<-driver.reads
+	}
+	select {
+	case <-driver.reads:
+		t.Error("the driver was read after Stop returned")
+	case <-time.After(2 * readBackoff):
+	}
+}
@@ -0,0 +1,309 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/logger"
+)
+
+// The accessLogFormat setting selects the format of the access log lines
+const (
+	// accessLogJSON writes an accessEntry as a JSON object per line
+	accessLogJSON = "json"
+
+	// accessLogCombined writes the Combined Log Format, followed by the duration, operation name & query hash
+	accessLogCombined = "combined"
+
+	defaultAccessLogMaxSize    = 100 // megabytes
+	defaultAccessLogMaxBackups = 5
+)
+
+// accessLogger is the logger the access log is written to when no accessLogFile is set, so that its level can be
+// set apart from the trigger logger
+var accessLogger = logger.GetLogger("trigger-flogo-graphql-access")
+
+// accessEntry is a line of the access log. The server fills in the HTTP fields, while the GraphQL handler adds the
+// operation of the request.
+type accessEntry struct {
+	Time           time.Time `json:"time"`
+	RemoteAddr     string    `json:"remoteAddr"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	Proto          string    `json:"proto"`
+	Status         int       `json:"status"`
+	Bytes          int64     `json:"bytes"`
+	DurationMs     float64   `json:"durationMs"`
+	OperationName  string    `json:"operationName,omitempty"`
+	QueryHash      string    `json:"queryHash,omitempty"`
+	Tenant         string    `json:"tenant,omitempty"`
+	ClaimedSubject string    `json:"claimedSubject,omitempty"`
+	Referer        string    `json:"referer,omitempty"`
+	UserAgent      string    `json:"userAgent,omitempty"`
+
+	uri string
+}
+
+// accessKey is the context key of the access log entry of a request
+const accessKey contextKey = "access"
+
+// accessEntryFromContext returns the access log entry of the request, nil when access logging is disabled
+func accessEntryFromContext(ctx context.Context) *accessEntry {
+	entry, _ := ctx.Value(accessKey).(*accessEntry)
+	return entry
+}
+
+// queryHash returns the SHA-256 hash of the query text, as used to identify persisted queries
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// claimedSubject returns the user of basic authentication, or the subject of a bearer JWT, as claimed by the client.
+// The trigger verifies neither the password nor the signature, so the subject must not be trusted to identify the
+// client.
+func claimedSubject(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return ""
+	}
+
+	parts := strings.Split(auth[7:], ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	claims := struct {
+		Subject string `json:"sub"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	return claims.Subject
+}
+
+// accessLog writes an entry per request, to a rotating file or else to the accessLogger
+type accessLog struct {
+	format string
+	file   *rotatingFile
+}
+
+// newAccessLog creates the access log configured by the trigger settings, nil is returned when it is disabled
+func newAccessLog(settings map[string]interface{}) (*accessLog, error) {
+	if enabled, _ := data.CoerceToBoolean(settings["accessLog"]); !enabled {
+		return nil, nil
+	}
+
+	l := &accessLog{format: accessLogJSON}
+	if format, _ := settings["accessLogFormat"].(string); format != "" {
+		if format != accessLogJSON && format != accessLogCombined {
+			return nil, fmt.Errorf("invalid accessLogFormat '%s'", format)
+		}
+		l.format = format
+	}
+
+	path, _ := settings["accessLogFile"].(string)
+	if path == "" {
+		return l, nil
+	}
+
+	maxSize, err := parseLimit("accessLogMaxSize", settings["accessLogMaxSize"], defaultAccessLogMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBackups, err := parseLimit("accessLogMaxBackups", settings["accessLogMaxBackups"], defaultAccessLogMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.file, err = openRotatingFile(path, maxSize<<20, int(maxBackups)); err != nil {
+		return nil, fmt.Errorf("unable to open the access log: %v", err)
+	}
+
+	return l, nil
+}
+
+func (l *accessLog) log(entry *accessEntry) {
+	var line string
+	if l.format == accessLogCombined {
+		line = combinedLine(entry)
+	} else {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			log.Errorf("Unable to write the access log: %v", err)
+			return
+		}
+		line = string(b)
+	}
+
+	if l.file == nil {
+		accessLogger.Info(line)
+		return
+	}
+
+	if _, err := io.WriteString(l.file, line+"\n"); err != nil {
+		log.Errorf("Unable to write the access log: %v", err)
+	}
+}
+
+func (l *accessLog) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// combinedLine formats the entry in the Combined Log Format, with the claimed subject as the user, followed by the duration
+// in milliseconds, the operation name and the query hash
+func combinedLine(entry *accessEntry) string {
+	host := entry.RemoteAddr
+	if i := strings.LastIndex(host, ":"); i > 0 {
+		host = host[:i]
+	}
+
+	bytes := "-"
+	if entry.Bytes > 0 {
+		bytes = fmt.Sprint(entry.Bytes)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s \"%s\" \"%s\" %.3f \"%s\" %s",
+		host, dash(entry.ClaimedSubject), entry.Time.Format("02/Jan/2006:15:04:05 -0700"), entry.Method, entry.uri, entry.Proto,
+		entry.Status, bytes, dash(entry.Referer), dash(entry.UserAgent), entry.DurationMs, dash(entry.OperationName),
+		dash(entry.QueryHash))
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// accessRecorder records the status & size of a response
+type accessRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *accessRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *accessRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher, which incremental responses rely on
+func (r *accessRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// rotatingFile is a file which is renamed to path.1 once it reaches maxSize bytes, the previous path.1 becoming
+// path.2 and so on up to maxBackups files
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(b)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(b)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	if f.maxBackups > 0 {
+		for i := f.maxBackups - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+		}
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// jwt returns an unsigned JWT holding the claims
+func jwt(claims string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(claims)) + ".signature"
+}
+
+func TestAccessLogFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	query := `query User { user(id: "1") { name } }`
+
+	tests := []struct {
+		format string
+		check  func(line string) error
+	}{
+		{accessLogJSON, func(line string) error {
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return err
+			}
+			want := map[string]interface{}{
+				"method":         "POST",
+				"path":           "/graphql",
+				"proto":          "HTTP/1.1",
+				"status":         float64(200),
+				"operationName":  "User",
+				"queryHash":      queryHash(query),
+				"claimedSubject": "dan",
+				"userAgent":      "test",
+			}
+			for k, v := range want {
+				if entry[k] != v {
+					return fmt.Errorf("got %s %v, want %v", k, entry[k], v)
+				}
+			}
+			if _, ok := entry["subject"]; ok {
+				return fmt.Errorf("unexpected subject")
+			}
+			return nil
+		}},
+		{accessLogCombined, func(line string) error {
+			pattern := `^192\.0\.2\.1 - dan \[[^\]]+\] "POST /graphql HTTP/1\.1" 200 \d+ "-" "test" \d+\.\d{3} "User" ` + queryHash(query) + `$`
+			if !regexp.MustCompile(pattern).MatchString(line) {
+				return fmt.Errorf("does not match %s", pattern)
+			}
+			return nil
+		}},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, test.format+".log")
+		trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, map[string]interface{}{
+			"accessLog":       true,
+			"accessLogFormat": test.format,
+			"accessLogFile":   path,
+		}), newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})))
+
+		// The access log is written by the handler the server wraps the trigger in once it listens
+		handler := &serverHandler{trg.server.Handler, make(chan bool, 1), "test", trg.server.accessLog}
+
+		body, _ := json.Marshal(map[string]interface{}{"query": query, "operationName": "User"})
+		r := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+		r.Header.Set("Content-Type", mediaTypeJSON)
+		r.Header.Set("Authorization", "Bearer "+jwt(`{"sub":"dan"}`))
+		r.Header.Set("User-Agent", "test")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		if err := trg.server.accessLog.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		if len(lines) != 1 {
+			t.Errorf("%s: got %d lines: %s", test.format, len(lines), content)
+			continue
+		}
+		if err := test.check(lines[0]); err != nil {
+			t.Errorf("%s: %v: %s", test.format, err, lines[0])
+		}
+	}
+}
+
+func TestClaimedSubject(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		want          string
+	}{
+		{"basic", "Basic " + base64.StdEncoding.EncodeToString([]byte("dan:secret")), "dan"},
+		{"bearer", "Bearer " + jwt(`{"sub":"matt","admin":true}`), "matt"},
+		{"bearer case", "bearer " + jwt(`{"sub":"matt"}`), "matt"},
+		{"opaque token", "Bearer 0123456789abcdef", ""},
+		{"invalid claims", "Bearer " + jwt(`not json`), ""},
+		{"none", "", ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/graphql", nil)
+		if test.authorization != "" {
+			r.Header.Set("Authorization", test.authorization)
+		}
+
+		if got := claimedSubject(r); got != test.want {
+			t.Errorf("%s: got '%s', want '%s'", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "access.log")
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each line fits in the size limit on its own, but not along with the previous one
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{path, "line 4\n"},
+		{path + ".1", "line 3\n"},
+		{path + ".2", "line 2\n"},
+	}
+
+	for _, test := range tests {
+		content, err := ioutil.ReadFile(test.file)
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if string(content) != test.want {
+			t.Errorf("%s: got %q, want %q", test.file, content, test.want)
+		}
+	}
+
+	// Older files are dropped beyond maxBackups
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected %s.3 to be removed, got %v", path, err)
+	}
+}
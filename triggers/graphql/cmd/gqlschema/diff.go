@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+)
+
+// breakingChanges returns the changes from the old to the new schema which can break the queries of existing clients:
+// removed types, fields, arguments, enum values & union members, changed types, and arguments or input fields which
+// became required
+func breakingChanges(oldSchema, newSchema *graphql.Schema) []string {
+	var changes []string
+	report := func(format string, args ...interface{}) {
+		changes = append(changes, fmt.Sprintf(format, args...))
+	}
+
+	oldTypes, newTypes := oldSchema.TypeMap(), newSchema.TypeMap()
+	for _, name := range sortedTypeNames(oldTypes) {
+		oldType := oldTypes[name]
+		newType, ok := newTypes[name]
+		if !ok {
+			report("Type '%s' was removed", name)
+			continue
+		}
+
+		if kind(oldType) != kind(newType) {
+			report("Type '%s' changed from %s to %s", name, kind(oldType), kind(newType))
+			continue
+		}
+
+		switch oldType := oldType.(type) {
+		case *graphql.Object:
+			diffFields(report, name, oldType.Fields(), newType.(*graphql.Object).Fields())
+			for _, iface := range oldType.Interfaces() {
+				if !implements(newType.(*graphql.Object), iface.Name()) {
+					report("Type '%s' no longer implements interface '%s'", name, iface.Name())
+				}
+			}
+		case *graphql.Interface:
+			diffFields(report, name, oldType.Fields(), newType.(*graphql.Interface).Fields())
+		case *graphql.Union:
+			members := make(map[string]bool)
+			for _, member := range newType.(*graphql.Union).Types() {
+				members[member.Name()] = true
+			}
+			for _, member := range oldType.Types() {
+				if !members[member.Name()] {
+					report("Type '%s' was removed from union '%s'", member.Name(), name)
+				}
+			}
+		case *graphql.Enum:
+			values := make(map[string]bool)
+			for _, value := range newType.(*graphql.Enum).Values() {
+				values[value.Name] = true
+			}
+			for _, value := range oldType.Values() {
+				if !values[value.Name] {
+					report("Value '%s' was removed from enum '%s'", value.Name, name)
+				}
+			}
+		case *graphql.InputObject:
+			oldFields, newFields := oldType.Fields(), newType.(*graphql.InputObject).Fields()
+			for _, fieldName := range sortedInputFieldNames(oldFields) {
+				newField, ok := newFields[fieldName]
+				if !ok {
+					report("Input field '%s.%s' was removed", name, fieldName)
+				} else if !safeInputChange(oldFields[fieldName].Type, newField.Type) {
+					report("Input field '%s.%s' changed type from %s to %s", name, fieldName, oldFields[fieldName].Type, newField.Type)
+				}
+			}
+			for _, fieldName := range sortedInputFieldNames(newFields) {
+				if _, ok := oldFields[fieldName]; !ok && isRequired(newFields[fieldName].Type, newFields[fieldName].DefaultValue) {
+					report("Required input field '%s.%s' was added", name, fieldName)
+				}
+			}
+		}
+	}
+
+	return changes
+}
+
+func diffFields(report func(string, ...interface{}), typeName string, oldFields, newFields graphql.FieldDefinitionMap) {
+	names := make([]string, 0, len(oldFields))
+	for name := range oldFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldField := oldFields[name]
+		newField, ok := newFields[name]
+		if !ok {
+			report("Field '%s.%s' was removed", typeName, name)
+			continue
+		}
+
+		if !safeOutputChange(oldField.Type, newField.Type) {
+			report("Field '%s.%s' changed type from %s to %s", typeName, name, oldField.Type, newField.Type)
+		}
+
+		oldArgs, newArgs := argsByName(oldField.Args), argsByName(newField.Args)
+		for _, argName := range sortedArgNames(oldArgs) {
+			oldArg := oldArgs[argName]
+			newArg, ok := newArgs[argName]
+			if !ok {
+				report("Argument '%s' of field '%s.%s' was removed", argName, typeName, name)
+				continue
+			}
+
+			switch {
+			case !isRequired(oldArg.Type, oldArg.DefaultValue) && isRequired(newArg.Type, newArg.DefaultValue):
+				report("Argument '%s' of field '%s.%s' became required", argName, typeName, name)
+			case !safeInputChange(oldArg.Type, newArg.Type):
+				report("Argument '%s' of field '%s.%s' changed type from %s to %s", argName, typeName, name, oldArg.Type, newArg.Type)
+			}
+		}
+
+		for _, argName := range sortedArgNames(newArgs) {
+			if _, ok := oldArgs[argName]; !ok && isRequired(newArgs[argName].Type, newArgs[argName].DefaultValue) {
+				report("Required argument '%s' of field '%s.%s' was added", argName, typeName, name)
+			}
+		}
+	}
+}
+
+// safeOutputChange reports whether clients reading a value of the old type can read one of the new type, which holds
+// when the types are the same or the new type only drops nullability
+func safeOutputChange(oldType, newType graphql.Type) bool {
+	if newNonNull, ok := newType.(*graphql.NonNull); ok {
+		if oldNonNull, ok := oldType.(*graphql.NonNull); ok {
+			return safeOutputChange(oldNonNull.OfType, newNonNull.OfType)
+		}
+		return safeOutputChange(oldType, newNonNull.OfType)
+	}
+
+	switch oldType := oldType.(type) {
+	case *graphql.NonNull:
+		return false
+	case *graphql.List:
+		newList, ok := newType.(*graphql.List)
+		return ok && safeOutputChange(oldType.OfType, newList.OfType)
+	}
+
+	return oldType.Name() == newType.Name() && kind(oldType) == kind(newType)
+}
+
+// safeInputChange reports whether the values clients send for the old type are valid for the new type, which holds
+// when the types are the same or the new type only allows null
+func safeInputChange(oldType, newType graphql.Type) bool {
+	if oldNonNull, ok := oldType.(*graphql.NonNull); ok {
+		if newNonNull, ok := newType.(*graphql.NonNull); ok {
+			return safeInputChange(oldNonNull.OfType, newNonNull.OfType)
+		}
+		return safeInputChange(oldNonNull.OfType, newType)
+	}
+
+	switch newType := newType.(type) {
+	case *graphql.NonNull:
+		return false
+	case *graphql.List:
+		oldList, ok := oldType.(*graphql.List)
+		return ok && safeInputChange(oldList.OfType, newType.OfType)
+	}
+
+	return oldType.Name() == newType.Name() && kind(oldType) == kind(newType)
+}
+
+// isRequired reports whether an argument or input field must be supplied
+func isRequired(typ graphql.Type, defaultValue interface{}) bool {
+	_, nonNull := typ.(*graphql.NonNull)
+	return nonNull && defaultValue == nil
+}
+
+func implements(obj *graphql.Object, ifaceName string) bool {
+	for _, iface := range obj.Interfaces() {
+		if iface.Name() == ifaceName {
+			return true
+		}
+	}
+	return false
+}
+
+func kind(typ graphql.Type) string {
+	switch typ.(type) {
+	case *graphql.Scalar:
+		return "SCALAR"
+	case *graphql.Object:
+		return "OBJECT"
+	case *graphql.Interface:
+		return "INTERFACE"
+	case *graphql.Union:
+		return "UNION"
+	case *graphql.Enum:
+		return "ENUM"
+	case *graphql.InputObject:
+		return "INPUT_OBJECT"
+	case *graphql.List:
+		return "LIST"
+	case *graphql.NonNull:
+		return "NON_NULL"
+	}
+	return "UNKNOWN"
+}
+
+func argsByName(args []*graphql.Argument) map[string]*graphql.Argument {
+	byName := make(map[string]*graphql.Argument, len(args))
+	for _, arg := range args {
+		byName[arg.Name()] = arg
+	}
+	return byName
+}
+
+func sortedArgNames(args map[string]*graphql.Argument) []string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedInputFieldNames(fields graphql.InputObjectFieldMap) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedTypeNames(types graphql.TypeMap) []string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
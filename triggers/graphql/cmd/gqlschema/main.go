@@ -0,0 +1,187 @@
+// Command gqlschema prints the schema served by the GraphQL trigger of a Flogo app, and reports the breaking changes
+// between the schemas of two versions of an app.
+//
+// Usage:
+//
+//	gqlschema export [-format sdl|json] [-trigger id] [-tenant id] app.json
+//	gqlschema diff [-trigger id] [-tenant id] old.json new.json
+//
+// diff exits with status 1 when it finds breaking changes.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/graphql-go/graphql"
+	gqltrigger "github.com/mellistibco/flogo-activities/triggers/graphql"
+)
+
+const triggerRef = "github.com/mellistibco/flogo-activities/triggers/graphql"
+
+// errUsage is returned for invalid command lines
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command and returns its exit status: 1 when diff finds breaking changes, 2 when the command fails
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		return usage(stderr)
+	}
+
+	var err error
+	switch args[0] {
+	case "export":
+		err = export(args[1:], stdout, stderr)
+	case "diff":
+		var breaking bool
+		if breaking, err = diff(args[1:], stdout, stderr); err == nil && breaking {
+			return 1
+		}
+	default:
+		return usage(stderr)
+	}
+
+	if err == errUsage {
+		return usage(stderr)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "gqlschema:", err)
+		return 2
+	}
+
+	return 0
+}
+
+func usage(stderr io.Writer) int {
+	fmt.Fprintln(stderr, "usage: gqlschema export [-format sdl|json] [-trigger id] [-tenant id] app.json")
+	fmt.Fprintln(stderr, "       gqlschema diff [-trigger id] [-tenant id] old.json new.json")
+	return 2
+}
+
+func export(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "sdl", "the output format, sdl or json for the introspection result")
+	triggerID := flags.String("trigger", "", "the id of the GraphQL trigger, required when the app has several")
+	tenant := flags.String("tenant", "", "the tenant whose schema is exported, the default schema when empty")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+
+	schema, err := loadSchema(flags.Arg(0), *triggerID, *tenant)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "sdl":
+		fmt.Fprint(stdout, printSchema(schema))
+	case "json":
+		result := graphql.Do(graphql.Params{Schema: *schema, RequestString: introspectionQuery})
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("unable to introspect the schema: %v", result.Errors)
+		}
+
+		out, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(out))
+	default:
+		return fmt.Errorf("invalid format '%s'", *format)
+	}
+
+	return nil
+}
+
+func diff(args []string, stdout, stderr io.Writer) (bool, error) {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	triggerID := flags.String("trigger", "", "the id of the GraphQL trigger, required when the apps have several")
+	tenant := flags.String("tenant", "", "the tenant whose schemas are compared, the default schema when empty")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return false, errUsage
+	}
+
+	oldSchema, err := loadSchema(flags.Arg(0), *triggerID, *tenant)
+	if err != nil {
+		return false, err
+	}
+
+	newSchema, err := loadSchema(flags.Arg(1), *triggerID, *tenant)
+	if err != nil {
+		return false, err
+	}
+
+	changes := breakingChanges(oldSchema, newSchema)
+	for _, change := range changes {
+		fmt.Fprintln(stdout, change)
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "No breaking changes")
+	}
+
+	return len(changes) > 0, nil
+}
+
+// app is the part of a Flogo app needed to build the schema of its GraphQL triggers
+type app struct {
+	Triggers []struct {
+		ID       string                 `json:"id"`
+		Ref      string                 `json:"ref"`
+		Settings map[string]interface{} `json:"settings"`
+		Handlers []struct {
+			Settings map[string]interface{} `json:"settings"`
+		} `json:"handlers"`
+	} `json:"triggers"`
+}
+
+// loadSchema builds the schema served by the GraphQL trigger of the app
+func loadSchema(path, triggerID, tenant string) (*graphql.Schema, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var a app
+	if err := json.Unmarshal(content, &a); err != nil {
+		return nil, fmt.Errorf("invalid app '%s': %v", path, err)
+	}
+
+	var config *trigger.Config
+	for _, trg := range a.Triggers {
+		if trg.Ref != triggerRef || (triggerID != "" && trg.ID != triggerID) {
+			continue
+		}
+		if config != nil {
+			return nil, fmt.Errorf("app '%s' has several GraphQL triggers, select one with -trigger", path)
+		}
+
+		config = &trigger.Config{Id: trg.ID, Ref: trg.Ref, Settings: trg.Settings}
+		for _, handler := range trg.Handlers {
+			config.Handlers = append(config.Handlers, &trigger.HandlerConfig{Settings: handler.Settings})
+		}
+	}
+
+	if config == nil {
+		return nil, fmt.Errorf("no GraphQL trigger found in app '%s'", path)
+	}
+
+	schema, err := gqltrigger.BuildSchema(config, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build the schema of app '%s': %v", path, err)
+	}
+
+	return schema, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+// writeApp writes an app whose GraphQL trigger serves the user type with the given fields, along with the query
+// fields of the remote endpoint when it is set
+func writeApp(t *testing.T, dir, name, fields, remoteEndpoint string) string {
+	settings := map[string]interface{}{
+		"operation": "QUERY",
+		"types":     json.RawMessage(`[{"Name":"user","Fields":` + fields + `}]`),
+		"schema":    json.RawMessage(`{"Query":{"Name":"Query","Fields":{"user":{"Type":"user","Args":{"id":{"Type":"graphql.String"}}}}}}`),
+	}
+	if remoteEndpoint != "" {
+		settings["remoteEndpoint"] = remoteEndpoint
+	}
+
+	content, err := json.Marshal(map[string]interface{}{
+		"triggers": []interface{}{
+			map[string]interface{}{
+				"id":       "graphql",
+				"ref":      triggerRef,
+				"settings": settings,
+				"handlers": []interface{}{
+					map[string]interface{}{"settings": map[string]interface{}{"resolverFor": "user"}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newRemote serves a schema whose account field takes an id argument of the given type
+func newRemote(t *testing.T, idType graphql.Input) *httptest.Server {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"account": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: idType}},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(graphql.Do(graphql.Params{Schema: schema, RequestString: req.Query}))
+	}))
+}
+
+func TestDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gqlschema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	optional := newRemote(t, graphql.ID)
+	defer optional.Close()
+	required := newRemote(t, graphql.NewNonNull(graphql.ID))
+	defer required.Close()
+
+	const fields = `{"id":{"Type":"graphql.String"},"name":{"Type":"graphql.String"}}`
+
+	tests := []struct {
+		name       string
+		oldFields  string
+		newFields  string
+		oldRemote  string
+		newRemote  string
+		status     int
+		wantOutput string
+	}{
+		{"unchanged", fields, fields, "", "", 0, "No breaking changes"},
+		{"added field", fields, `{"id":{"Type":"graphql.String"},"name":{"Type":"graphql.String"},"age":{"Type":"graphql.Int"}}`, "", "", 0, "No breaking changes"},
+		{"removed field", fields, `{"id":{"Type":"graphql.String"}}`, "", "", 1, "Field 'user.name' was removed"},
+		{"type change", fields, `{"id":{"Type":"graphql.Int"},"name":{"Type":"graphql.String"}}`, "", "", 1, "Field 'user.id' changed type from String to Int"},
+		{"argument became required", fields, fields, optional.URL, required.URL, 1, "Argument 'id' of field 'Query.account' became required"},
+	}
+
+	for _, test := range tests {
+		oldApp := writeApp(t, dir, test.name+"-old.json", test.oldFields, test.oldRemote)
+		newApp := writeApp(t, dir, test.name+"-new.json", test.newFields, test.newRemote)
+
+		var stdout, stderr bytes.Buffer
+		status := run([]string{"diff", oldApp, newApp}, &stdout, &stderr)
+
+		if status != test.status {
+			t.Errorf("%s: got status %d, want %d: %s%s", test.name, status, test.status, stdout.String(), stderr.String())
+		}
+		if !strings.Contains(stdout.String(), test.wantOutput) {
+			t.Errorf("%s: got output %q, want %q", test.name, stdout.String(), test.wantOutput)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := [][]string{
+		nil,
+		{"unknown"},
+		{"diff", "old.json"},
+		{"diff", "-unknown", "old.json", "new.json"},
+		{"diff", "missing-old.json", "missing-new.json"},
+		{"export", "-format", "xml", "missing.json"},
+	}
+
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if status := run(args, &stdout, &stderr); status != 2 {
+			t.Errorf("%v: got status %d, want 2", args, status)
+		}
+		if stderr.Len() == 0 {
+			t.Errorf("%v: expected a message on stderr", args)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } }
+}`
+
+// builtInScalars and builtInDirectives are part of every schema, so they are not printed
+var builtInScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+var builtInDirectives = map[string]bool{"include": true, "skip": true, "deprecated": true}
+
+// printSchema prints the schema in the GraphQL schema definition language, types & fields being sorted by name
+func printSchema(schema *graphql.Schema) string {
+	var b strings.Builder
+
+	if query := schema.QueryType(); query != nil && query.Name() != "Query" {
+		fmt.Fprintf(&b, "schema {\n  query: %s\n}\n\n", query.Name())
+	}
+
+	directives := append([]*graphql.Directive(nil), schema.Directives()...)
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	for _, directive := range directives {
+		if builtInDirectives[directive.Name] {
+			continue
+		}
+		printDescription(&b, "", directive.Description)
+		fmt.Fprintf(&b, "directive @%s%s on %s\n\n", directive.Name, printArgs(directive.Args), strings.Join(directive.Locations, " | "))
+	}
+
+	typeMap := schema.TypeMap()
+	names := make([]string, 0, len(typeMap))
+	for name := range typeMap {
+		if !strings.HasPrefix(name, "__") && !builtInScalars[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		printType(&b, typeMap[name])
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func printType(b *strings.Builder, typ graphql.Type) {
+	printDescription(b, "", typ.Description())
+
+	switch typ := typ.(type) {
+	case *graphql.Scalar:
+		fmt.Fprintf(b, "scalar %s\n", typ.Name())
+	case *graphql.Object:
+		fmt.Fprintf(b, "type %s", typ.Name())
+		var ifaces []string
+		for _, iface := range typ.Interfaces() {
+			ifaces = append(ifaces, iface.Name())
+		}
+		if len(ifaces) > 0 {
+			sort.Strings(ifaces)
+			fmt.Fprintf(b, " implements %s", strings.Join(ifaces, " & "))
+		}
+		printFields(b, typ.Fields())
+	case *graphql.Interface:
+		fmt.Fprintf(b, "interface %s", typ.Name())
+		printFields(b, typ.Fields())
+	case *graphql.Union:
+		var members []string
+		for _, member := range typ.Types() {
+			members = append(members, member.Name())
+		}
+		sort.Strings(members)
+		fmt.Fprintf(b, "union %s = %s\n", typ.Name(), strings.Join(members, " | "))
+	case *graphql.Enum:
+		fmt.Fprintf(b, "enum %s {\n", typ.Name())
+		values := append([]*graphql.EnumValueDefinition(nil), typ.Values()...)
+		sort.Slice(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+		for _, value := range values {
+			printDescription(b, "  ", value.Description)
+			fmt.Fprintf(b, "  %s%s\n", value.Name, printDeprecated(value.DeprecationReason))
+		}
+		b.WriteString("}\n")
+	case *graphql.InputObject:
+		fmt.Fprintf(b, "input %s {\n", typ.Name())
+		fields := typ.Fields()
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			field := fields[name]
+			printDescription(b, "  ", field.Description())
+			fmt.Fprintf(b, "  %s: %s%s\n", name, field.Type, printDefault(field.DefaultValue, field.Type))
+		}
+		b.WriteString("}\n")
+	}
+}
+
+func printFields(b *strings.Builder, fields graphql.FieldDefinitionMap) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b.WriteString(" {\n")
+	for _, name := range names {
+		field := fields[name]
+		printDescription(b, "  ", field.Description)
+		fmt.Fprintf(b, "  %s%s: %s%s\n", name, printArgs(field.Args), field.Type, printDeprecated(field.DeprecationReason))
+	}
+	b.WriteString("}\n")
+}
+
+func printArgs(args []*graphql.Argument) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	sorted := append([]*graphql.Argument(nil), args...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+
+	var parts []string
+	for _, arg := range sorted {
+		parts = append(parts, fmt.Sprintf("%s: %s%s", arg.Name(), arg.Type, printDefault(arg.DefaultValue, arg.Type)))
+	}
+
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func printDefault(value interface{}, typ graphql.Input) string {
+	if value == nil {
+		return ""
+	}
+	return " = " + printValue(value, typ)
+}
+
+// printValue prints a default value as a GraphQL literal
+func printValue(value interface{}, typ graphql.Input) string {
+	switch t := typ.(type) {
+	case *graphql.NonNull:
+		return printValue(value, t.OfType)
+	case *graphql.List:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice {
+			return printValue(value, t.OfType)
+		}
+		var items []string
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, printValue(rv.Index(i).Interface(), t.OfType))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *graphql.Enum:
+		for _, v := range t.Values() {
+			if reflect.DeepEqual(v.Value, value) {
+				return v.Name
+			}
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		quoted, _ := json.Marshal(s)
+		return string(quoted)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func printDeprecated(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	if reason == graphql.DefaultDeprecationReason {
+		return " @deprecated"
+	}
+
+	quoted, _ := json.Marshal(reason)
+	return fmt.Sprintf(" @deprecated(reason: %s)", quoted)
+}
+
+func printDescription(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+
+	if !strings.Contains(description, "\n") {
+		quoted, _ := json.Marshal(description)
+		fmt.Fprintf(b, "%s%s\n", indent, quoted)
+		return
+	}
+
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(description, "\n") {
+		fmt.Fprintf(b, "%s%s\n", indent, strings.Replace(line, `"""`, `\"""`, -1))
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+}
@@ -0,0 +1,176 @@
+package graphql
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultCompressionMinSize = 1024
+
+// negotiateEncoding returns the content coding to compress a response with, gzip or deflate, based on the
+// Accept-Encoding header of the request. An empty string is returned when neither is accepted.
+func negotiateEncoding(acceptEncoding string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding == "" {
+			continue
+		}
+
+		weight := 1.0
+		for _, param := range params[1:] {
+			if kv := strings.SplitN(strings.TrimSpace(param), "=", 2); len(kv) == 2 && strings.TrimSpace(kv[0]) == "q" {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+					weight = v
+				}
+			}
+		}
+		q[coding] = weight
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{"gzip", "deflate"} {
+		weight, ok := q[coding]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && weight > bestQ {
+			best, bestQ = coding, weight
+		}
+	}
+
+	return best
+}
+
+// compressHandler compresses the responses of next with the coding accepted by the client, once they reach minSize
+// bytes
+func compressHandler(next http.Handler, minSize int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == "HEAD" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+		defer cw.Close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter buffers the start of a response until it holds minSize bytes, in which case the response is
+// compressed, or until it ends, in which case it is sent as is
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	started bool
+	writer  io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.started {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) >= w.minSize {
+			if err := w.start(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(b), nil
+	}
+
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// start sends the headers and the buffered start of the response, compressing it if asked to and if the response
+// is not already encoded
+func (w *compressWriter) start(compress bool) error {
+	w.started = true
+
+	if w.status == 0 {
+		return nil
+	}
+
+	header := w.Header()
+	if compress && header.Get("Content-Encoding") == "" && w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding)
+
+		if w.encoding == "gzip" {
+			w.writer = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			w.writer = zlib.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.writer != nil {
+		_, err = w.writer.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush implements http.Flusher, the parts of incremental responses are compressed together once the response has
+// reached minSize bytes
+func (w *compressWriter) Flush() {
+	if !w.started {
+		if err := w.start(len(w.buf) >= w.minSize); err != nil {
+			return
+		}
+	}
+
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close sends the response if it is still buffered, and ends the compressed stream
+func (w *compressWriter) Close() error {
+	if !w.started {
+		if err := w.start(false); err != nil {
+			return err
+		}
+	}
+
+	if w.writer != nil {
+		return w.writer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package graphql
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// sizedReply replies with a name as long as the id argument
+func sizedReply(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+	args, _ := triggerData["args"].(map[string]interface{})
+	id, _ := args["id"].(string)
+	n, _ := strconv.Atoi(id)
+	return map[string]interface{}{"data": map[string]interface{}{"name": strings.Repeat("a", n)}}, nil
+}
+
+// decodeBody decompresses a response body of the content coding
+func decodeBody(t *testing.T, encoding string, body io.Reader) []byte {
+	var err error
+	switch encoding {
+	case "gzip":
+		body, err = gzip.NewReader(body)
+	case "deflate":
+		body, err = zlib.NewReader(body)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestCompression(t *testing.T) {
+	trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, map[string]interface{}{"compression": true, "compressionMinSize": 256}),
+		newTestHandler("user", sizedReply))
+
+	tests := []struct {
+		acceptEncoding string
+		size           int
+		encoding       string
+	}{
+		{"gzip", 512, "gzip"},
+		{"gzip", 16, ""},
+		{"deflate", 512, "deflate"},
+		{"deflate;q=0.5, gzip", 512, "gzip"},
+		{"gzip;q=0, deflate", 512, "deflate"},
+		{"identity", 512, ""},
+		{"", 512, ""},
+	}
+
+	for _, test := range tests {
+		body, _ := json.Marshal(map[string]interface{}{"query": `{ user(id: "` + strconv.Itoa(test.size) + `") { name } }`})
+		r := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+		r.Header.Set("Content-Type", mediaTypeJSON)
+		if test.acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", test.acceptEncoding)
+		}
+
+		w := httptest.NewRecorder()
+		trg.ServeHTTP(w, r)
+
+		if encoding := w.Header().Get("Content-Encoding"); encoding != test.encoding {
+			t.Errorf("Accept-Encoding '%s', size %d: got encoding '%s', want '%s'", test.acceptEncoding, test.size, encoding, test.encoding)
+			continue
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding '%s', size %d: got Vary '%s'", test.acceptEncoding, test.size, vary)
+		}
+
+		var result map[string]interface{}
+		if err := json.Unmarshal(decodeBody(t, test.encoding, w.Body), &result); err != nil {
+			t.Errorf("Accept-Encoding '%s', size %d: %v", test.acceptEncoding, test.size, err)
+			continue
+		}
+
+		user, _ := result["data"].(map[string]interface{})["user"].(map[string]interface{})
+		if name, _ := user["name"].(string); len(name) != test.size {
+			t.Errorf("Accept-Encoding '%s', size %d: got %v", test.acceptEncoding, test.size, result)
+		}
+	}
+}
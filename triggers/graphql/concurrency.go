@@ -0,0 +1,164 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// newSemaphore returns a channel allowing up to limit concurrent holders, or nil when limit is not positive
+func newSemaphore(limit interface{}) (chan struct{}, error) {
+	if limit == nil {
+		return nil, nil
+	}
+
+	n, err := data.CoerceToInteger(limit)
+	if err != nil {
+		return nil, fmt.Errorf("maxConcurrency must be an integer")
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+
+	return make(chan struct{}, n), nil
+}
+
+// parseTimeout parses a timeout setting, 0 is returned when it is not set
+func parseTimeout(setting string, val interface{}) (time.Duration, error) {
+	if val == nil || val == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(fmt.Sprint(val))
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid %s '%v'", setting, val)
+	}
+
+	return timeout, nil
+}
+
+// resolverReply is the outcome of a resolver executed concurrently
+type resolverReply struct {
+	value interface{}
+	err   error
+}
+
+// concurrentResolver executes the resolver of a handler in its own goroutine, so that sibling fields are resolved in
+// parallel. The execution waits for the fields the handler depends on to be resolved, for a slot of the handler and
+// then of the trigger concurrency caps, and fails the field if it does not complete within the timeout of the
+// handler, or else the resolverTimeout of the trigger. The context of the handler is cancelled when the field fails
+// this way.
+func (t *GraphQLTrigger) concurrentResolver(handler *trigger.Handler, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	timeout := t.resolverTimeout
+	if handlerTimeout := t.handlerTimeouts[handler]; handlerTimeout > 0 {
+		timeout = handlerTimeout
+	}
+	dependsOn := t.handlerDependencies[handler]
+
+	// The slot of the handler is acquired first, so that requests waiting for a busy handler don't hold the slots of
+	// the trigger
+	var semaphores []chan struct{}
+	if sem := t.handlerSemaphores[handler]; sem != nil {
+		semaphores = append(semaphores, sem)
+	}
+	if t.semaphore != nil {
+		semaphores = append(semaphores, t.semaphore)
+	}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		parent := p.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		ctx, cancel := context.WithCancel(parent)
+		p.Context = ctx
+
+		replies := make(chan resolverReply, 1)
+		abandon := make(chan struct{})
+
+		exec := executionFromContext(parent)
+		key := p.Info.FieldName
+		if p.Info.Path != nil {
+			key = fmt.Sprint(p.Info.Path.Key)
+		}
+
+		go func(p graphql.ResolveParams) {
+			defer func() {
+				if r := recover(); r != nil {
+					replies <- resolverReply{err: fmt.Errorf("resolver for field '%s' failed: %v", p.Info.FieldName, r)}
+				}
+			}()
+
+			// The handler sees the request context stored by the handlers of the fields it depends on, the slots are
+			// only acquired once they are resolved so that waiting for them doesn't hold a slot
+			if exec != nil {
+				defer exec.resolved(key)
+
+				changes, ok := exec.await(key, dependsOn, abandon)
+				if !ok {
+					return
+				}
+				p.Context = withRequestView(ctx, exec, key, changes)
+			}
+
+			for _, sem := range semaphores {
+				select {
+				case sem <- struct{}{}:
+					defer func(sem chan struct{}) { <-sem }(sem)
+				case <-abandon:
+					return
+				}
+			}
+
+			value, err := resolve(p)
+			replies <- resolverReply{value: value, err: err}
+		}(p)
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if timeout > 0 {
+			timer = time.NewTimer(timeout)
+			expired = timer.C
+		}
+
+		wait := func() (interface{}, error) {
+			defer cancel()
+			if timer != nil {
+				defer timer.Stop()
+			}
+
+			select {
+			case reply := <-replies:
+				return reply.value, reply.err
+			case <-expired:
+				close(abandon)
+				return nil, &statusError{
+					status:  http.StatusGatewayTimeout,
+					message: fmt.Sprintf("resolver for field '%s' timed out after %v", p.Info.FieldName, timeout),
+					safe:    true,
+				}
+			case <-parent.Done():
+				close(abandon)
+				return nil, parent.Err()
+			}
+		}
+
+		// The fields of a mutation are executed serially
+		if op, ok := p.Info.Operation.(*ast.OperationDefinition); ok && op.Operation == ast.OperationTypeMutation {
+			return wait()
+		}
+
+		// graphql-go completes the fields of a query before calling the thunks they return, so the siblings of this
+		// field are started before the result is awaited
+		return func() (interface{}, error) {
+			return wait()
+		}, nil
+	}
+}
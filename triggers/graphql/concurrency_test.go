@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/graphql-go/graphql"
+)
+
+// awaitThunk calls the resolver and the thunk it returns
+func awaitThunk(t *testing.T, resolve graphql.FieldResolveFn, p graphql.ResolveParams) (interface{}, error) {
+	value, err := resolve(p)
+	if err != nil {
+		return value, err
+	}
+
+	thunk, ok := value.(func() (interface{}, error))
+	if !ok {
+		t.Fatalf("expected a thunk, got %v", value)
+	}
+	return thunk()
+}
+
+func TestConcurrentResolverTimeout(t *testing.T) {
+	handler := &trigger.Handler{}
+	trg := &GraphQLTrigger{resolverTimeout: 10 * time.Millisecond}
+
+	cancelled := make(chan struct{})
+	resolve := trg.concurrentResolver(handler, func(p graphql.ResolveParams) (interface{}, error) {
+		<-p.Context.Done()
+		close(cancelled)
+		return nil, p.Context.Err()
+	})
+
+	_, err := awaitThunk(t, resolve, graphql.ResolveParams{Context: context.Background()})
+	if sErr, ok := err.(*statusError); !ok || sErr.status != http.StatusGatewayTimeout {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("the context of the resolver was not cancelled")
+	}
+}
+
+func TestConcurrentResolverSemaphores(t *testing.T) {
+	handler := &trigger.Handler{}
+	trg := &GraphQLTrigger{
+		semaphore:         make(chan struct{}, 1),
+		handlerSemaphores: map[*trigger.Handler]chan struct{}{handler: make(chan struct{}, 1)},
+	}
+
+	// The handler is busy, a request waiting for it must not hold the slot of the trigger
+	trg.handlerSemaphores[handler] <- struct{}{}
+
+	resolve := trg.concurrentResolver(handler, func(p graphql.ResolveParams) (interface{}, error) {
+		return "resolved", nil
+	})
+
+	thunk, err := resolve(graphql.ResolveParams{Context: context.Background()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case trg.semaphore <- struct{}{}:
+		<-trg.semaphore
+	case <-time.After(100 * time.Millisecond):
+		t.Error("the slot of the trigger is held while waiting for the handler")
+	}
+
+	<-trg.handlerSemaphores[handler]
+	if value, err := thunk.(func() (interface{}, error))(); value != "resolved" || err != nil {
+		t.Errorf("got %v, %v", value, err)
+	}
+}
+
+func TestConcurrentResolverErrorExtensions(t *testing.T) {
+	trg := &GraphQLTrigger{}
+	resolve := trg.concurrentResolver(&trigger.Handler{}, func(p graphql.ResolveParams) (interface{}, error) {
+		return nil, &statusError{status: http.StatusNotFound, message: "no such user", safe: true}
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"user": &graphql.Field{Type: graphql.String, Resolve: resolve}},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ user }`})
+	restoreExtensions(result)
+
+	if len(result.Errors) != 1 || result.Errors[0].Message != "no such user" {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	if code := result.Errors[0].Extensions["code"]; code != "NOT_FOUND" {
+		t.Errorf("unexpected extensions %v", result.Errors[0].Extensions)
+	}
+	if status := responseStatus(statusPolicyAny, result); status != http.StatusNotFound {
+		t.Errorf("got status %d", status)
+	}
+}
+
+func TestSiblingHandlersRunConcurrently(t *testing.T) {
+	userStarted := make(chan struct{})
+	addressStarted := make(chan struct{})
+
+	// Each handler waits for the other one to start, which fails if the fields are resolved one after the other
+	await := func(started, other chan struct{}, reply map[string]interface{}) replyFunc {
+		return func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+			close(started)
+			select {
+			case <-other:
+				return map[string]interface{}{"data": reply}, nil
+			case <-time.After(time.Second):
+				return nil, errors.New("the sibling handler did not start")
+			}
+		}
+	}
+
+	trg := newTestTrigger(t, testSettings(t, siblingTypes, siblingSchema, nil),
+		newTestHandler("user", await(userStarted, addressStarted, map[string]interface{}{"name": "Matt"})),
+		newTestHandler("address", await(addressStarted, userStarted, map[string]interface{}{"city": "Palo Alto"})),
+	)
+
+	result := decodeResult(t, postQuery(trg, mediaTypeJSON, `{ user { name } address { city } }`))
+	want := map[string]interface{}{
+		"user":    map[string]interface{}{"name": "Matt"},
+		"address": map[string]interface{}{"city": "Palo Alto"},
+	}
+	if !reflect.DeepEqual(result["data"], want) || result["errors"] != nil {
+		t.Errorf("got %v, want %v", result, want)
+	}
+}
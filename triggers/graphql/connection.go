@@ -0,0 +1,345 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/graphql-go/graphql"
+)
+
+// Relay style connections, see https://facebook.github.io/relay/graphql/connections.htm
+
+const cursorPrefix = "arrayconnection:"
+
+// pageInfoFields are the fields of the PageInfo type
+var pageInfoFields = []string{"hasNextPage", "hasPreviousPage", "startCursor", "endCursor"}
+
+// connectionTypes creates and caches the connection & edge types of a schema
+type connectionTypes struct {
+	pageInfo    *graphql.Object
+	connections map[string]*graphql.Object
+
+	// types are the types of the schema, which the generated types must not collide with
+	types map[string]graphql.Output
+}
+
+func newConnectionTypes(types map[string]graphql.Output) *connectionTypes {
+	return &connectionTypes{connections: make(map[string]*graphql.Object), types: types}
+}
+
+// connectionFor returns the XConnection type for the node type X, along with its XEdge type. A PageInfo type of the
+// schema, e.g. of a remote schema, is reused when it has the fields of a page info, any other collision is an error.
+func (ct *connectionTypes) connectionFor(nodeType graphql.Output) (*graphql.Object, error) {
+	name := nodeType.Name()
+	if conn, ok := ct.connections[name]; ok {
+		return conn, nil
+	}
+
+	for _, generated := range []string{name + "Edge", name + "Connection"} {
+		if _, ok := ct.types[generated]; ok {
+			return nil, fmt.Errorf("type '%s' collides with the type generated for the connections of '%s'", generated, name)
+		}
+	}
+
+	if ct.pageInfo == nil {
+		if typ, ok := ct.types["PageInfo"]; ok {
+			obj, ok := typ.(*graphql.Object)
+			if !ok || !hasFields(obj, pageInfoFields) {
+				return nil, fmt.Errorf("type 'PageInfo' collides with the type generated for connections, it must be an object with the fields %s", strings.Join(pageInfoFields, ", "))
+			}
+			ct.pageInfo = obj
+		}
+	}
+
+	if ct.pageInfo == nil {
+		ct.pageInfo = graphql.NewObject(graphql.ObjectConfig{
+			Name: "PageInfo",
+			Fields: graphql.Fields{
+				"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"startCursor":     &graphql.Field{Type: graphql.String},
+				"endCursor":       &graphql.Field{Type: graphql.String},
+			},
+		})
+	}
+
+	edge := graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Edge",
+		Fields: graphql.Fields{
+			"node":   &graphql.Field{Type: nodeType},
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	conn := graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Connection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewList(edge)},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(ct.pageInfo)},
+			"totalCount": &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	ct.connections[name] = conn
+	return conn, nil
+}
+
+func hasFields(obj *graphql.Object, names []string) bool {
+	fields := obj.Fields()
+	for _, name := range names {
+		if _, ok := fields[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// addConnectionArgs adds the first/after/last/before arguments to the arguments of a connection field
+func addConnectionArgs(args graphql.FieldConfigArgument) {
+	args["first"] = &graphql.ArgumentConfig{Type: graphql.Int}
+	args["after"] = &graphql.ArgumentConfig{Type: graphql.String}
+	args["last"] = &graphql.ArgumentConfig{Type: graphql.Int}
+	args["before"] = &graphql.ArgumentConfig{Type: graphql.String}
+}
+
+// pagination holds the normalized pagination arguments of a connection field
+type pagination struct {
+	first  *int
+	last   *int
+	after  string
+	before string
+
+	// afterOffset and beforeOffset are the offsets the cursors point to, -1 when not set
+	afterOffset  int
+	beforeOffset int
+}
+
+// newPagination extracts the pagination arguments from the field arguments, the remaining arguments are returned
+func newPagination(fieldArgs map[string]interface{}) (map[string]interface{}, *pagination, error) {
+	page := &pagination{afterOffset: -1, beforeOffset: -1}
+	args := make(map[string]interface{}, len(fieldArgs))
+
+	for k, v := range fieldArgs {
+		var err error
+
+		switch k {
+		case "first", "last":
+			n, ok := v.(int)
+			if !ok {
+				return nil, nil, &argumentError{argument: k, message: "must be an integer"}
+			}
+			if n < 0 {
+				return nil, nil, &argumentError{argument: k, message: "must be a non-negative integer"}
+			}
+			if k == "first" {
+				page.first = &n
+			} else {
+				page.last = &n
+			}
+		case "after":
+			page.after, _ = v.(string)
+			page.afterOffset, err = cursorToOffset(page.after)
+		case "before":
+			page.before, _ = v.(string)
+			page.beforeOffset, err = cursorToOffset(page.before)
+		default:
+			args[k] = v
+		}
+
+		if err != nil {
+			return nil, nil, &argumentError{argument: k, message: "is not a valid cursor"}
+		}
+	}
+
+	return args, page, nil
+}
+
+// toMap converts the pagination to the value passed to the handler. Along with the raw arguments, it holds the
+// offset of the first requested item and the maximum number of items requested from there. The requested items lie
+// between the cursors, where first keeps those at the start and last those at the end. The offset is left out when
+// last is supplied without before, as it depends on the length of the list, and the limit when the requested items
+// run to the end of the list.
+func (page *pagination) toMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"after":  page.after,
+		"before": page.before,
+	}
+
+	if page.first != nil {
+		m["first"] = *page.first
+	}
+	if page.last != nil {
+		m["last"] = *page.last
+	}
+
+	// end is the offset after the last requested item, -1 for the end of the list
+	start, end := page.afterOffset+1, page.beforeOffset
+	if page.first != nil && (end < 0 || start+*page.first < end) {
+		end = start + *page.first
+	}
+
+	if page.last != nil {
+		if end < 0 {
+			return m
+		}
+		start = maxInt(start, end-*page.last)
+	}
+
+	m["offset"] = start
+	if end >= 0 {
+		m["limit"] = maxInt(end-start, 0)
+	}
+
+	return m
+}
+
+func offsetToCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func cursorToOffset(cursor string) (int, error) {
+	if cursor == "" {
+		return -1, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return -1, err
+	}
+
+	if !strings.HasPrefix(string(decoded), cursorPrefix) {
+		return -1, fmt.Errorf("invalid cursor '%s'", cursor)
+	}
+
+	return strconv.Atoi(strings.TrimPrefix(string(decoded), cursorPrefix))
+}
+
+// connectionFromSlice builds the connection for a slice of a list, which starts at sliceStart and whose full length
+// is totalCount
+func connectionFromSlice(slice []interface{}, page *pagination, sliceStart, totalCount int) map[string]interface{} {
+	sliceEnd := sliceStart + len(slice)
+
+	beforeOffset := totalCount
+	if page.before != "" {
+		beforeOffset = page.beforeOffset
+	}
+
+	startOffset := maxInt(maxInt(sliceStart-1, page.afterOffset), -1) + 1
+	endOffset := minInt(minInt(sliceEnd, beforeOffset), totalCount)
+
+	if page.first != nil {
+		endOffset = minInt(endOffset, startOffset+*page.first)
+	}
+	if page.last != nil {
+		startOffset = maxInt(startOffset, endOffset-*page.last)
+	}
+
+	edges := make([]interface{}, 0)
+	for i := startOffset; i < endOffset; i++ {
+		if i-sliceStart < 0 || i-sliceStart >= len(slice) {
+			continue
+		}
+		edges = append(edges, map[string]interface{}{
+			"node":   slice[i-sliceStart],
+			"cursor": offsetToCursor(i),
+		})
+	}
+
+	pageInfo := map[string]interface{}{
+		"hasPreviousPage": false,
+		"hasNextPage":     false,
+	}
+
+	if len(edges) > 0 {
+		pageInfo["startCursor"] = edges[0].(map[string]interface{})["cursor"]
+		pageInfo["endCursor"] = edges[len(edges)-1].(map[string]interface{})["cursor"]
+	}
+
+	if page.last != nil {
+		lowerBound := 0
+		if page.after != "" {
+			lowerBound = page.afterOffset + 1
+		}
+		pageInfo["hasPreviousPage"] = startOffset > lowerBound
+	}
+
+	if page.first != nil {
+		upperBound := totalCount
+		if page.before != "" {
+			upperBound = page.beforeOffset
+		}
+		pageInfo["hasNextPage"] = endOffset < upperBound
+	}
+
+	return map[string]interface{}{
+		"edges":      edges,
+		"pageInfo":   pageInfo,
+		"totalCount": totalCount,
+	}
+}
+
+// connectionResolver resolves a connection field, the handler receives the field arguments without the pagination
+// arguments, which are passed separately. The handler replies with the list, or a slice of it along with the
+// sliceStart and totalCount of the full list.
+func connectionResolver(handler *trigger.Handler) graphql.FieldResolveFn {
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		args, page, err := newPagination(p.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		triggerData := map[string]interface{}{
+			"args":       args,
+			"pagination": page.toMap(),
+			"tenant":     tenantFromContext(p.Context),
+		}
+
+		results, err := handle(p.Context, handler, triggerData)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := replyError(results); err != nil {
+			return nil, err
+		}
+
+		items, err := data.CoerceToArray(replyValue(results, "data"))
+		if err != nil {
+			return nil, err
+		}
+
+		sliceStart, err := data.CoerceToInteger(replyValue(results, "sliceStart"))
+		if err != nil {
+			return nil, err
+		}
+
+		totalCount := sliceStart + len(items)
+		if val := replyValue(results, "totalCount"); val != nil {
+			if totalCount, err = data.CoerceToInteger(val); err != nil {
+				return nil, err
+			}
+		}
+
+		return connectionFromSlice(items, page, sliceStart, totalCount), nil
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,181 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestConnectionFromSlice(t *testing.T) {
+	list := []interface{}{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		name        string
+		args        map[string]interface{}
+		slice       []interface{}
+		sliceStart  int
+		totalCount  int
+		nodes       []interface{}
+		hasPrevious bool
+		hasNext     bool
+	}{
+		{"no arguments", nil, list, 0, 5, list, false, false},
+		{"first", map[string]interface{}{"first": 2}, list, 0, 5, []interface{}{"a", "b"}, false, true},
+		{"first all", map[string]interface{}{"first": 5}, list, 0, 5, list, false, false},
+		{"first 0", map[string]interface{}{"first": 0}, list, 0, 5, []interface{}{}, false, true},
+		{"last", map[string]interface{}{"last": 2}, list, 0, 5, []interface{}{"d", "e"}, true, false},
+		{"last 0", map[string]interface{}{"last": 0}, list, 0, 5, []interface{}{}, true, false},
+		{"first after", map[string]interface{}{"first": 2, "after": offsetToCursor(1)}, list, 0, 5, []interface{}{"c", "d"}, false, true},
+		{"last before", map[string]interface{}{"last": 2, "before": offsetToCursor(3)}, list, 0, 5, []interface{}{"b", "c"}, true, false},
+		{"after and before", map[string]interface{}{"after": offsetToCursor(0), "before": offsetToCursor(4)}, list, 0, 5, []interface{}{"b", "c", "d"}, false, false},
+		{"after last item", map[string]interface{}{"after": offsetToCursor(4)}, list, 0, 5, []interface{}{}, false, false},
+		{"empty list", map[string]interface{}{"first": 2}, []interface{}{}, 0, 0, []interface{}{}, false, false},
+		{"slice", map[string]interface{}{"first": 1, "after": offsetToCursor(1)}, []interface{}{"c", "d"}, 2, 5, []interface{}{"c"}, false, true},
+		{"slice past the page", map[string]interface{}{"first": 3, "after": offsetToCursor(1)}, []interface{}{"c", "d"}, 2, 5, []interface{}{"c", "d"}, false, true},
+	}
+
+	for _, test := range tests {
+		_, page, err := newPagination(test.args)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		conn := connectionFromSlice(test.slice, page, test.sliceStart, test.totalCount)
+
+		nodes := []interface{}{}
+		for _, edge := range conn["edges"].([]interface{}) {
+			nodes = append(nodes, edge.(map[string]interface{})["node"])
+		}
+		if !reflect.DeepEqual(nodes, test.nodes) {
+			t.Errorf("%s: got nodes %v, want %v", test.name, nodes, test.nodes)
+		}
+
+		pageInfo := conn["pageInfo"].(map[string]interface{})
+		if pageInfo["hasPreviousPage"] != test.hasPrevious || pageInfo["hasNextPage"] != test.hasNext {
+			t.Errorf("%s: got page info %v", test.name, pageInfo)
+		}
+		if conn["totalCount"] != test.totalCount {
+			t.Errorf("%s: got totalCount %v", test.name, conn["totalCount"])
+		}
+	}
+}
+
+func TestCursors(t *testing.T) {
+	for _, offset := range []int{0, 1, 42} {
+		if got, err := cursorToOffset(offsetToCursor(offset)); err != nil || got != offset {
+			t.Errorf("cursor of offset %d decoded to %d, %v", offset, got, err)
+		}
+	}
+
+	if got, err := cursorToOffset(""); err != nil || got != -1 {
+		t.Errorf("empty cursor decoded to %d, %v", got, err)
+	}
+
+	invalid := []string{
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("othercursor:1")),
+		base64.StdEncoding.EncodeToString([]byte(cursorPrefix + "x")),
+	}
+	for _, cursor := range invalid {
+		if _, err := cursorToOffset(cursor); err == nil {
+			t.Errorf("expected an error for cursor '%s'", cursor)
+		}
+	}
+}
+
+func TestNewPagination(t *testing.T) {
+	args, page, err := newPagination(map[string]interface{}{"first": 2, "after": offsetToCursor(3), "id": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, map[string]interface{}{"id": "1"}) {
+		t.Errorf("got arguments %v", args)
+	}
+
+	want := map[string]interface{}{"first": 2, "limit": 2, "offset": 4, "after": offsetToCursor(3), "before": ""}
+	if got := page.toMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got pagination %v, want %v", got, want)
+	}
+
+	invalid := []map[string]interface{}{
+		{"first": -1},
+		{"last": "2"},
+		{"after": "not a cursor"},
+		{"before": base64.StdEncoding.EncodeToString([]byte("othercursor:1"))},
+	}
+	for _, fieldArgs := range invalid {
+		if _, _, err := newPagination(fieldArgs); err == nil {
+			t.Errorf("expected an error for %v", fieldArgs)
+		} else if _, ok := err.(*argumentError); !ok {
+			t.Errorf("expected an argument error for %v, got %v", fieldArgs, err)
+		}
+	}
+}
+
+func TestPaginationToMap(t *testing.T) {
+	tests := []struct {
+		args   map[string]interface{}
+		offset interface{}
+		limit  interface{}
+	}{
+		{map[string]interface{}{}, 0, nil},
+		{map[string]interface{}{"first": 2}, 0, 2},
+		{map[string]interface{}{"first": 2, "after": offsetToCursor(3)}, 4, 2},
+		{map[string]interface{}{"before": offsetToCursor(10)}, 0, 10},
+		{map[string]interface{}{"after": offsetToCursor(3), "before": offsetToCursor(10)}, 4, 6},
+		{map[string]interface{}{"last": 2, "before": offsetToCursor(10)}, 8, 2},
+		{map[string]interface{}{"last": 20, "before": offsetToCursor(10)}, 0, 10},
+		{map[string]interface{}{"last": 20, "after": offsetToCursor(3), "before": offsetToCursor(10)}, 4, 6},
+		{map[string]interface{}{"first": 5, "last": 2, "after": offsetToCursor(3)}, 7, 2},
+		{map[string]interface{}{"first": 5, "before": offsetToCursor(2)}, 0, 2},
+
+		// The end of the list is unknown
+		{map[string]interface{}{"last": 2}, nil, nil},
+		{map[string]interface{}{"last": 2, "after": offsetToCursor(3)}, nil, nil},
+	}
+
+	for _, test := range tests {
+		_, page, err := newPagination(test.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := page.toMap()
+		if got["offset"] != test.offset || got["limit"] != test.limit {
+			t.Errorf("%v: got offset %v and limit %v, want %v and %v", test.args, got["offset"], got["limit"], test.offset, test.limit)
+		}
+	}
+}
+
+func TestConnectionTypeCollisions(t *testing.T) {
+	user := graphql.NewObject(graphql.ObjectConfig{Name: "user", Fields: graphql.Fields{"id": &graphql.Field{Type: graphql.String}}})
+	pageInfo := graphql.NewObject(graphql.ObjectConfig{Name: "PageInfo", Fields: graphql.Fields{
+		"hasNextPage":     &graphql.Field{Type: graphql.Boolean},
+		"hasPreviousPage": &graphql.Field{Type: graphql.Boolean},
+		"startCursor":     &graphql.Field{Type: graphql.String},
+		"endCursor":       &graphql.Field{Type: graphql.String},
+	}})
+
+	// A PageInfo type with the fields of a page info is reused
+	conn, err := newConnectionTypes(map[string]graphql.Output{"user": user, "PageInfo": pageInfo}).connectionFor(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ := conn.Fields()["pageInfo"].Type.(*graphql.NonNull).OfType; typ != pageInfo {
+		t.Errorf("the PageInfo type of the schema is not reused")
+	}
+
+	collisions := []graphql.Output{
+		graphql.NewObject(graphql.ObjectConfig{Name: "PageInfo", Fields: graphql.Fields{"total": &graphql.Field{Type: graphql.Int}}}),
+		graphql.NewObject(graphql.ObjectConfig{Name: "userEdge", Fields: graphql.Fields{"id": &graphql.Field{Type: graphql.String}}}),
+		graphql.NewObject(graphql.ObjectConfig{Name: "userConnection", Fields: graphql.Fields{"id": &graphql.Field{Type: graphql.String}}}),
+	}
+	for _, typ := range collisions {
+		if _, err := newConnectionTypes(map[string]graphql.Output{"user": user, typ.Name(): typ}).connectionFor(user); err == nil {
+			t.Errorf("expected an error for a type named '%s'", typ.Name())
+		}
+	}
+}
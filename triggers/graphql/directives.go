@@ -0,0 +1,293 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// DirectiveFn transforms the value resolved for a field, args holds the arguments of the directive
+type DirectiveFn func(value interface{}, args map[string]interface{}) (interface{}, error)
+
+type directive struct {
+	name  string
+	args  graphql.FieldConfigArgument
+	apply DirectiveFn
+}
+
+var directivesMu sync.RWMutex
+var directives = map[string]*directive{}
+
+func init() {
+	RegisterDirective("uppercase", nil, mapStrings(strings.ToUpper))
+	RegisterDirective("lowercase", nil, mapStrings(strings.ToLower))
+	RegisterDirective("dateFormat", graphql.FieldConfigArgument{
+		"format": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+	}, dateFormat)
+	RegisterDirective("mask", graphql.FieldConfigArgument{
+		"keep": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 4},
+	}, mask)
+}
+
+// RegisterDirective registers a directive, which can then be applied to fields in the types & schema settings or in
+// queries. args declares the arguments of the directive.
+func RegisterDirective(name string, args graphql.FieldConfigArgument, fn DirectiveFn) {
+	directivesMu.Lock()
+	defer directivesMu.Unlock()
+
+	if args == nil {
+		args = graphql.FieldConfigArgument{}
+	}
+
+	directives[name] = &directive{name: name, args: args, apply: fn}
+}
+
+func lookupDirective(name string) (*directive, bool) {
+	directivesMu.RLock()
+	defer directivesMu.RUnlock()
+
+	d, ok := directives[name]
+	return d, ok
+}
+
+// schemaDirectives returns the registered directives along with the directives of the specification and @defer &
+// @stream, to be declared by the schema
+func schemaDirectives() []*graphql.Directive {
+	directivesMu.RLock()
+	defer directivesMu.RUnlock()
+
+	names := make([]string, 0, len(directives))
+	for name := range directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	schemaDirectives := append([]*graphql.Directive{}, graphql.SpecifiedDirectives...)
+	schemaDirectives = append(schemaDirectives, deferDirective, streamDirective)
+	for _, name := range names {
+		schemaDirectives = append(schemaDirectives, graphql.NewDirective(graphql.DirectiveConfig{
+			Name:      name,
+			Locations: []string{graphql.DirectiveLocationField, graphql.DirectiveLocationFieldDefinition},
+			Args:      directives[name].args,
+		}))
+	}
+
+	return schemaDirectives
+}
+
+// appliedDirective is a directive applied to a field, along with its arguments
+type appliedDirective struct {
+	directive *directive
+	args      map[string]interface{}
+}
+
+// buildDirectives builds the directives applied to a field definition. They are listed by name, or as objects holding
+// the name and args of the directive.
+func buildDirectives(fieldName string, directiveDefs []interface{}) ([]*appliedDirective, error) {
+	var applied []*appliedDirective
+
+	for _, def := range directiveDefs {
+		var name string
+		var args map[string]interface{}
+
+		switch def := def.(type) {
+		case string:
+			name = def
+		case map[string]interface{}:
+			name, _ = def["name"].(string)
+			args, _ = def["args"].(map[string]interface{})
+		}
+
+		d, ok := lookupDirective(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown directive '%v' on field '%s'", def, fieldName)
+		}
+
+		coerced, err := coerceDirectiveArgs(d, args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments for directive '%s' on field '%s': %v", d.name, fieldName, err)
+		}
+
+		applied = append(applied, &appliedDirective{directive: d, args: coerced})
+	}
+
+	return applied, nil
+}
+
+// coerceDirectiveArgs matches the arguments to those declared by the directive, parsing their values and filling in
+// default values
+func coerceDirectiveArgs(d *directive, args map[string]interface{}) (map[string]interface{}, error) {
+	coerced := make(map[string]interface{}, len(d.args))
+
+	for name, argDef := range d.args {
+		var value interface{}
+		for k, v := range args {
+			if strings.EqualFold(k, name) {
+				value = v
+			}
+		}
+
+		if value == nil {
+			value = argDef.DefaultValue
+		} else if scalar, ok := graphql.GetNullable(argDef.Type).(*graphql.Scalar); ok {
+			value = scalar.ParseValue(value)
+		}
+
+		if value == nil {
+			if _, ok := argDef.Type.(*graphql.NonNull); ok {
+				return nil, fmt.Errorf("argument '%s' is required", name)
+			}
+			continue
+		}
+
+		coerced[name] = value
+	}
+
+	return coerced, nil
+}
+
+// queryDirectives returns the registered directives applied to the field in the query
+func queryDirectives(p graphql.ResolveParams) []*appliedDirective {
+	if len(p.Info.FieldASTs) == 0 {
+		return nil
+	}
+
+	var applied []*appliedDirective
+	for _, dirAST := range p.Info.FieldASTs[0].Directives {
+		d, ok := lookupDirective(dirAST.Name.Value)
+		if !ok {
+			continue
+		}
+
+		args := make(map[string]interface{}, len(dirAST.Arguments))
+		for _, arg := range dirAST.Arguments {
+			if variable, ok := arg.Value.(*ast.Variable); ok {
+				args[arg.Name.Value] = p.Info.VariableValues[variable.Name.Value]
+			} else {
+				args[arg.Name.Value] = valueFromAST(arg.Value)
+			}
+		}
+
+		// The arguments have been validated against the schema
+		coerced, _ := coerceDirectiveArgs(d, args)
+		applied = append(applied, &appliedDirective{directive: d, args: coerced})
+	}
+
+	return applied
+}
+
+// directiveResolver applies the directives of the field definition, and then those of the query, to the value
+// resolved for the field. The default resolver is used when resolve is nil.
+func directiveResolver(fieldDirectives []*appliedDirective, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		value, err := resolve(p)
+		if err != nil {
+			return value, err
+		}
+
+		all := append(append([]*appliedDirective{}, fieldDirectives...), queryDirectives(p)...)
+		for _, applied := range all {
+			if value, err = applied.directive.apply(value, applied.args); err != nil {
+				return nil, fmt.Errorf("directive @%s failed: %v", applied.directive.name, err)
+			}
+		}
+
+		return value, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+// Built-in directives
+
+// mapStrings builds a directive applying fn to a string value, or to each string of a list
+func mapStrings(fn func(string) string) DirectiveFn {
+
+	return func(value interface{}, args map[string]interface{}) (interface{}, error) {
+		return mapValue(value, func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return fn(s), nil
+			}
+			return v, nil
+		})
+	}
+}
+
+// mapValue applies fn to the value, or to each item of a list
+func mapValue(value interface{}, fn func(interface{}) (interface{}, error)) (interface{}, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return fn(value)
+	}
+
+	mapped := make([]interface{}, len(items))
+	for i, item := range items {
+		v, err := fn(item)
+		if err != nil {
+			return nil, err
+		}
+		mapped[i] = v
+	}
+
+	return mapped, nil
+}
+
+// dateFormat formats a date using a Go time layout. Dates are either RFC 3339 strings, or unix times in seconds.
+func dateFormat(value interface{}, args map[string]interface{}) (interface{}, error) {
+	format, _ := args["format"].(string)
+
+	return mapValue(value, func(v interface{}) (interface{}, error) {
+		var t time.Time
+
+		switch v := v.(type) {
+		case nil:
+			return nil, nil
+		case time.Time:
+			t = v
+		case string:
+			var err error
+			if t, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, fmt.Errorf("'%s' is not an RFC 3339 date", v)
+			}
+		default:
+			secs, err := data.CoerceToLong(v)
+			if err != nil {
+				return nil, err
+			}
+			t = time.Unix(secs, 0).UTC()
+		}
+
+		return t.Format(format), nil
+	})
+}
+
+// mask replaces all but the last keep characters of a string with *
+func mask(value interface{}, args map[string]interface{}) (interface{}, error) {
+	keep, _ := args["keep"].(int)
+	if keep < 0 {
+		return nil, &argumentError{argument: "keep", message: "must be a non-negative integer"}
+	}
+
+	return mapValue(value, func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return v, nil
+		}
+
+		runes := []rune(s)
+		for i := 0; i < len(runes)-keep; i++ {
+			runes[i] = '*'
+		}
+		return string(runes), nil
+	})
+}
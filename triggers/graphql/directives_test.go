@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuiltinDirectives(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		directive string
+		args      map[string]interface{}
+		value     interface{}
+		want      interface{}
+		fails     bool
+	}{
+		{"uppercase", "uppercase", nil, "Alice", "ALICE", false},
+		{"uppercase list", "uppercase", nil, []interface{}{"a", "b", 1}, []interface{}{"A", "B", 1}, false},
+		{"uppercase non string", "uppercase", nil, 42, 42, false},
+		{"lowercase", "lowercase", nil, "Alice", "alice", false},
+		{"lowercase nil", "lowercase", nil, nil, nil, false},
+		{"dateFormat string", "dateFormat", map[string]interface{}{"format": "2006-01-02"}, "2024-03-01T12:30:00Z", "2024-03-01", false},
+		{"dateFormat unix time", "dateFormat", map[string]interface{}{"format": "15:04"}, date.Unix(), "12:30", false},
+		{"dateFormat time", "dateFormat", map[string]interface{}{"format": time.RFC822}, date, date.Format(time.RFC822), false},
+		{"dateFormat list", "dateFormat", map[string]interface{}{"format": "2006"}, []interface{}{"2024-03-01T12:30:00Z", nil}, []interface{}{"2024", nil}, false},
+		{"dateFormat invalid date", "dateFormat", map[string]interface{}{"format": "2006"}, "yesterday", nil, true},
+		{"mask default", "mask", nil, "4111111111111111", "************1111", false},
+		{"mask keep", "mask", map[string]interface{}{"keep": 2}, "secret", "****et", false},
+		{"mask keep 0", "mask", map[string]interface{}{"keep": 0}, "secret", "******", false},
+		{"mask keep all", "mask", map[string]interface{}{"keep": 10}, "secret", "secret", false},
+		{"mask runes", "mask", map[string]interface{}{"keep": 1}, "héllo", "****o", false},
+		{"mask non string", "mask", nil, 1234567, 1234567, false},
+		{"mask negative keep", "mask", map[string]interface{}{"keep": -1}, "secret", nil, true},
+	}
+
+	for _, test := range tests {
+		d, ok := lookupDirective(test.directive)
+		if !ok {
+			t.Fatalf("directive '%s' is not registered", test.directive)
+		}
+
+		args, err := coerceDirectiveArgs(d, test.args)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+
+		got, err := d.apply(test.value, args)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", test.name, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	d, _ := lookupDirective("mask")
+	if _, err := d.apply("secret", map[string]interface{}{"keep": -1}); err == nil {
+		t.Error("expected an error for a negative keep")
+	} else if argErr, ok := err.(*argumentError); !ok || argErr.argument != "keep" {
+		t.Errorf("expected an argument error for keep, got %v", err)
+	}
+}
+
+func TestBuildDirectives(t *testing.T) {
+	applied, err := buildDirectives("name", []interface{}{
+		"uppercase",
+		map[string]interface{}{"name": "mask", "args": map[string]interface{}{"KEEP": "2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || applied[0].directive.name != "uppercase" || applied[1].directive.name != "mask" {
+		t.Fatalf("got directives %v", applied)
+	}
+	if !reflect.DeepEqual(applied[1].args, map[string]interface{}{"keep": 2}) {
+		t.Errorf("got mask arguments %v", applied[1].args)
+	}
+
+	invalid := [][]interface{}{
+		{"unknown"},
+		{map[string]interface{}{"name": "dateFormat"}},
+		{map[string]interface{}{"args": map[string]interface{}{"keep": 1}}},
+	}
+	for _, defs := range invalid {
+		if _, err := buildDirectives("name", defs); err == nil {
+			t.Errorf("expected an error for %v", defs)
+		}
+	}
+}
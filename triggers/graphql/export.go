@@ -0,0 +1,59 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/graphql-go/graphql"
+)
+
+// BuildSchema builds the schema which a trigger of the given config serves for a tenant, the empty tenant being the
+// default schema. The handlers of the config decide which fields are served, as they do for the trigger, but the
+// schema is meant to be inspected: its fields can't be resolved.
+func BuildSchema(config *trigger.Config, tenant string) (*graphql.Schema, error) {
+	if config.Settings == nil {
+		return nil, fmt.Errorf("no Settings found for trigger '%s'", config.Id)
+	}
+
+	t := &GraphQLTrigger{config: config}
+	t.mock, _ = data.CoerceToBoolean(config.Settings["mock"])
+
+	if err := t.configureRemote(); err != nil {
+		return nil, fmt.Errorf("invalid settings for trigger '%s': %v", config.Id, err)
+	}
+
+	for _, handlerConfig := range config.Handlers {
+		t.handlers = append(t.handlers, trigger.NewHandlerAlt(&configHandler{settings: handlerConfig.Settings}))
+	}
+
+	return t.buildSchema(tenant)
+}
+
+// configHandler is a handler known from its config only, which fails when it is executed
+type configHandler struct {
+	settings map[string]interface{}
+}
+
+func (h *configHandler) Handle(ctx context.Context, triggerData map[string]interface{}) (map[string]*data.Attribute, error) {
+	return nil, fmt.Errorf("handler for field '%s' can't be executed", h.GetStringSetting("resolverFor"))
+}
+
+func (h *configHandler) GetSetting(setting string) (interface{}, bool) {
+	val, ok := h.settings[setting]
+	return val, ok
+}
+
+func (h *configHandler) GetOutput() map[string]interface{} {
+	return nil
+}
+
+func (h *configHandler) GetStringSetting(setting string) string {
+	val, _ := data.CoerceToString(h.settings[setting])
+	return val
+}
+
+func (h *configHandler) String() string {
+	return fmt.Sprintf("Handler for field '%s'", h.GetStringSetting("resolverFor"))
+}
@@ -0,0 +1,216 @@
+// Package graphqltest provides utilities for end-to-end testing of the GraphQL trigger without a Flogo engine: fake
+// handlers replying with canned values and recording the data they are called with, and an HTTP test server serving
+// a trigger built from a settings map.
+package graphqltest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/mellistibco/flogo-activities/triggers/graphql"
+)
+
+// Handler is a fake handler of the trigger. It replies with Reply, or with the result of ReplyFunc when it is set,
+// and records the trigger data of each call.
+type Handler struct {
+	// Settings are the handler settings, such as resolverFor & tenant
+	Settings map[string]interface{}
+
+	// Reply holds the reply attributes, such as data, status & error
+	Reply map[string]interface{}
+
+	// ReplyFunc computes the reply attributes from the trigger data, an error fails the handler
+	ReplyFunc func(triggerData map[string]interface{}) (map[string]interface{}, error)
+
+	mu    sync.Mutex
+	calls []map[string]interface{}
+}
+
+// NewHandler returns a handler resolving field with the given data
+func NewHandler(field string, reply interface{}) *Handler {
+	return &Handler{
+		Settings: map[string]interface{}{"resolverFor": field},
+		Reply:    map[string]interface{}{"data": reply},
+	}
+}
+
+// Handle implements trigger.HandlerInf.Handle
+func (h *Handler) Handle(ctx context.Context, triggerData map[string]interface{}) (map[string]*data.Attribute, error) {
+	h.mu.Lock()
+	h.calls = append(h.calls, triggerData)
+	h.mu.Unlock()
+
+	reply := h.Reply
+	if h.ReplyFunc != nil {
+		var err error
+		if reply, err = h.ReplyFunc(triggerData); err != nil {
+			return nil, err
+		}
+	}
+
+	attrs := make(map[string]*data.Attribute, len(reply))
+	for name, value := range reply {
+		attr, err := data.NewAttribute(name, data.TypeAny, value)
+		if err != nil {
+			return nil, err
+		}
+		attrs[name] = attr
+	}
+
+	return attrs, nil
+}
+
+// Calls returns the trigger data of the calls of the handler, in order
+func (h *Handler) Calls() []map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]map[string]interface{}(nil), h.calls...)
+}
+
+// Args returns the args the handler was called with, in order
+func (h *Handler) Args() []map[string]interface{} {
+	var args []map[string]interface{}
+	for _, call := range h.Calls() {
+		callArgs, _ := call["args"].(map[string]interface{})
+		args = append(args, callArgs)
+	}
+	return args
+}
+
+// GetSetting implements trigger.HandlerInf.GetSetting
+func (h *Handler) GetSetting(setting string) (interface{}, bool) {
+	val, ok := h.Settings[setting]
+	return val, ok
+}
+
+// GetOutput implements trigger.HandlerInf.GetOutput
+func (h *Handler) GetOutput() map[string]interface{} {
+	return nil
+}
+
+// GetStringSetting implements trigger.HandlerInf.GetStringSetting
+func (h *Handler) GetStringSetting(setting string) string {
+	val, _ := data.CoerceToString(h.Settings[setting])
+	return val
+}
+
+func (h *Handler) String() string {
+	return fmt.Sprintf("Fake handler for field '%s'", h.GetStringSetting("resolverFor"))
+}
+
+type initContext struct {
+	handlers []*trigger.Handler
+}
+
+func (c *initContext) GetHandlers() []*trigger.Handler {
+	return c.handlers
+}
+
+// NewTrigger builds and initializes a trigger from its settings & handlers. The port defaults to 0 and the operation
+// to QUERY, the trigger is not started.
+func NewTrigger(settings map[string]interface{}, handlers ...*Handler) (*graphql.GraphQLTrigger, error) {
+	config := &trigger.Config{Id: "graphqltest", Settings: make(map[string]interface{}, len(settings)+2)}
+	config.Settings["port"] = "0"
+	config.Settings["operation"] = "QUERY"
+	for k, v := range settings {
+		config.Settings[k] = v
+	}
+
+	ctx := &initContext{}
+	for _, handler := range handlers {
+		ctx.handlers = append(ctx.handlers, trigger.NewHandlerAlt(handler))
+	}
+
+	trg := graphql.NewFactory(nil).New(config).(*graphql.GraphQLTrigger)
+	if err := trg.Initialize(ctx); err != nil {
+		return nil, err
+	}
+
+	return trg, nil
+}
+
+// Server is an HTTP test server serving a trigger
+type Server struct {
+	*httptest.Server
+
+	// Trigger is the trigger being served
+	Trigger *graphql.GraphQLTrigger
+
+	// Path is the path the GraphQL endpoint is served on
+	Path string
+}
+
+// NewServer starts a server serving a trigger built by NewTrigger, it must be closed when done
+func NewServer(settings map[string]interface{}, handlers ...*Handler) (*Server, error) {
+	trg, err := NewTrigger(settings, handlers...)
+	if err != nil {
+		return nil, err
+	}
+
+	path, _ := settings["path"].(string)
+	return &Server{Server: httptest.NewServer(trg), Trigger: trg, Path: path}, nil
+}
+
+// Response is the decoded response to a GraphQL request
+type Response struct {
+	// Status is the HTTP status of the response
+	Status int
+
+	// Body is the raw body of the response, e.g. the message of a request rejected before execution
+	Body string
+
+	Data       map[string]interface{}   `json:"data"`
+	Errors     []map[string]interface{} `json:"errors"`
+	Extensions map[string]interface{}   `json:"extensions"`
+}
+
+// Query posts a query with its variables to the GraphQL endpoint as JSON, and decodes the response
+func (s *Server) Query(query string, variables map[string]interface{}) (*Response, error) {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Do(http.Header{"Content-Type": {"application/json"}, "Accept": {"application/graphql-response+json"}}, body)
+}
+
+// Do posts a request body with the given headers to the GraphQL endpoint, and decodes the response when it is JSON
+func (s *Server) Do(header http.Header, body []byte) (*Response, error) {
+	req, err := http.NewRequest("POST", s.URL+s.Path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Response{Status: resp.StatusCode, Body: string(content)}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/json" || mediaType == "application/graphql-response+json" {
+		if err := json.Unmarshal(content, result); err != nil {
+			return nil, fmt.Errorf("invalid response with status %d: %s", resp.StatusCode, content)
+		}
+	}
+
+	return result, nil
+}
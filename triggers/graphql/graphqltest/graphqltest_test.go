@@ -0,0 +1,143 @@
+package graphqltest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const types = `[{"Name":"user","Fields":{"id":{"Type":"graphql.String"},"name":{"Type":"graphql.String"}}}]`
+const schema = `{"Query":{"Name":"Query","Fields":{"user":{"Type":"user","Args":{"id":{"Type":"graphql.String"}}}}}}`
+
+func settings(t *testing.T) map[string]interface{} {
+	var typesSetting []interface{}
+	var schemaSetting map[string]interface{}
+	if err := json.Unmarshal([]byte(types), &typesSetting); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(schema), &schemaSetting); err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]interface{}{"path": "/graphql", "types": typesSetting, "schema": schemaSetting}
+}
+
+func TestQuery(t *testing.T) {
+	handler := NewHandler("user", map[string]interface{}{"id": "1", "name": "Matt"})
+
+	server, err := NewServer(settings(t), handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	resp, err := server.Query(`query($id: String) { user(id: $id) { name } }`, map[string]interface{}{"id": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("unexpected response %d: %s", resp.Status, resp.Body)
+	}
+
+	user, _ := resp.Data["user"].(map[string]interface{})
+	if user["name"] != "Matt" {
+		t.Errorf("unexpected data %v", resp.Data)
+	}
+
+	args := handler.Args()
+	if len(args) != 1 || args[0]["id"] != "1" {
+		t.Errorf("unexpected args %v", args)
+	}
+}
+
+func TestInvalidSettings(t *testing.T) {
+	s := settings(t)
+	s["maxBodySize"] = "lots"
+
+	if _, err := NewTrigger(s); err == nil {
+		t.Error("expected an error for invalid settings")
+	}
+}
+
+func TestReplyFunc(t *testing.T) {
+	handler := &Handler{
+		Settings: map[string]interface{}{"resolverFor": "user"},
+		ReplyFunc: func(triggerData map[string]interface{}) (map[string]interface{}, error) {
+			args, _ := triggerData["args"].(map[string]interface{})
+			if args["id"] == "" {
+				return nil, errors.New("missing id")
+			}
+			return map[string]interface{}{"data": map[string]interface{}{"name": "user " + fmt.Sprint(args["id"])}}, nil
+		},
+	}
+
+	server, err := NewServer(settings(t), handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	tests := []struct {
+		id    string
+		name  interface{}
+		error string
+	}{
+		{"1", "user 1", ""},
+		{"", nil, "missing id"},
+	}
+
+	for i, test := range tests {
+		resp, err := server.Query(`query($id: String) { user(id: $id) { name } }`, map[string]interface{}{"id": test.id})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		user, _ := resp.Data["user"].(map[string]interface{})
+		if user["name"] != test.name {
+			t.Errorf("id '%s': unexpected data %v", test.id, resp.Data)
+		}
+		if test.error != "" && (len(resp.Errors) != 1 || resp.Errors[0]["message"] != test.error) {
+			t.Errorf("id '%s': unexpected errors %v", test.id, resp.Errors)
+		}
+
+		// Every call is recorded, failing ones included
+		if calls := handler.Calls(); len(calls) != i+1 {
+			t.Errorf("id '%s': got %d calls, want %d", test.id, len(calls), i+1)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	s := settings(t)
+	s["maxBodySize"] = 64
+
+	server, err := NewServer(s, NewHandler("user", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	tests := []struct {
+		body   string
+		status int
+		data   bool
+	}{
+		{`{"query":"{ user(id: \"1\") { name } }"}`, http.StatusOK, true},
+		// Responses which are not JSON are returned as is
+		{`{"query":"{ user(id: \"a long enough id to exceed the limit of the body\") { name } }"}`, http.StatusRequestEntityTooLarge, false},
+	}
+
+	for _, test := range tests {
+		resp, err := server.Do(http.Header{"Content-Type": {"application/json"}}, []byte(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Status != test.status || (resp.Data != nil) != test.data || resp.Body == "" {
+			t.Errorf("%s: unexpected response %d: %s", test.body, resp.Status, resp.Body)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The probe endpoints, served on the port of the trigger whatever its path
+const (
+	healthPath  = "/healthz"
+	readyPath   = "/readyz"
+	versionPath = "/version"
+)
+
+// probeHandler serves the probe endpoints, other requests are passed to next
+func (t *GraphQLTrigger) probeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.URL.Path {
+		case healthPath:
+			writeProbe(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+		case readyPath:
+			if err := t.readiness(); err != nil {
+				writeProbe(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "unavailable", "reason": err.Error()})
+				return
+			}
+			writeProbe(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+		case versionPath:
+			writeProbe(w, http.StatusOK, t.version())
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// readiness returns why the trigger cannot serve requests, nil once its server is listening with a schema for every
+// tenant and until it is stopped
+func (t *GraphQLTrigger) readiness() error {
+	if atomic.LoadInt32(&t.draining) != 0 {
+		return fmt.Errorf("server is draining")
+	}
+
+	if atomic.LoadInt32(&t.listening) == 0 {
+		return fmt.Errorf("server is not listening")
+	}
+
+	for _, tenant := range t.tenantIDs() {
+		if t.currentSchema(tenant) == nil {
+			if tenant != "" {
+				return fmt.Errorf("no schema for tenant '%s'", tenant)
+			}
+			return fmt.Errorf("no schema")
+		}
+	}
+
+	return nil
+}
+
+// version returns the instance ID of the server and the hashes of the schemas being served
+func (t *GraphQLTrigger) version() map[string]interface{} {
+	info := map[string]interface{}{"serverInstanceId": t.server.InstanceID()}
+	if t.metadata != nil {
+		info["version"] = t.metadata.Version
+	}
+
+	tenantHashes := make(map[string]string)
+	for _, tenant := range t.tenantIDs() {
+		schema := t.currentSchema(tenant)
+		if schema == nil {
+			continue
+		}
+
+		if tenant == "" {
+			info["schemaHash"] = schemaHash(schema)
+		} else {
+			tenantHashes[tenant] = schemaHash(schema)
+		}
+	}
+
+	if len(tenantHashes) > 0 {
+		info["tenantSchemaHashes"] = tenantHashes
+	}
+
+	return info
+}
+
+func writeProbe(w http.ResponseWriter, status int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error(err)
+	}
+}
+
+// schemaHash returns a SHA-256 hash of the types of the schema, their fields & arguments, which changes whenever
+// the shape of the schema does
+func schemaHash(schema *graphql.Schema) string {
+	typeMap := schema.TypeMap()
+
+	names := make([]string, 0, len(typeMap))
+	for name := range typeMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "type %s\n", name)
+
+		switch typ := typeMap[name].(type) {
+		case *graphql.Object:
+			var ifaces []string
+			for _, iface := range typ.Interfaces() {
+				ifaces = append(ifaces, iface.Name())
+			}
+			hashLines(h, "implements", ifaces)
+			hashFields(h, typ.Fields())
+		case *graphql.Interface:
+			hashFields(h, typ.Fields())
+		case *graphql.InputObject:
+			var fields []string
+			for fieldName, field := range typ.Fields() {
+				fields = append(fields, fmt.Sprintf("%s: %s", fieldName, field.Type))
+			}
+			hashLines(h, "field", fields)
+		case *graphql.Enum:
+			var values []string
+			for _, value := range typ.Values() {
+				values = append(values, value.Name)
+			}
+			hashLines(h, "value", values)
+		case *graphql.Union:
+			var members []string
+			for _, member := range typ.Types() {
+				members = append(members, member.Name())
+			}
+			hashLines(h, "member", members)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hashFields(h hash.Hash, fields graphql.FieldDefinitionMap) {
+	var lines []string
+	for name, field := range fields {
+		var args []string
+		for _, arg := range field.Args {
+			args = append(args, fmt.Sprintf("%s: %s", arg.Name(), arg.Type))
+		}
+		sort.Strings(args)
+		lines = append(lines, fmt.Sprintf("%s%v: %s", name, args, field.Type))
+	}
+	hashLines(h, "field", lines)
+}
+
+// hashLines writes the lines in a stable order, as they are collected from maps
+func hashLines(h hash.Hash, prefix string, lines []string) {
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintf(h, "  %s %s\n", prefix, line)
+	}
+}
@@ -0,0 +1,122 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// probe requests a probe endpoint of the trigger and decodes its body
+func probe(t *testing.T, trg *GraphQLTrigger, path string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	trg.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid probe response %d: %s", w.Code, w.Body)
+	}
+	return w.Code, body
+}
+
+func TestProbes(t *testing.T) {
+	trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, map[string]interface{}{"drainDelay": "200ms"}),
+		newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})))
+
+	tests := []struct {
+		state  string
+		path   string
+		status int
+		reason string
+	}{
+		{"initialized", healthPath, http.StatusOK, ""},
+		{"initialized", readyPath, http.StatusServiceUnavailable, "server is not listening"},
+		{"started", healthPath, http.StatusOK, ""},
+		{"started", readyPath, http.StatusOK, ""},
+		{"draining", healthPath, http.StatusOK, ""},
+		{"draining", readyPath, http.StatusServiceUnavailable, "server is draining"},
+		{"stopped", readyPath, http.StatusServiceUnavailable, "server is draining"},
+	}
+
+	state := "initialized"
+	stopped := make(chan error, 1)
+
+	for _, test := range tests {
+		for state != test.state {
+			switch state {
+			case "initialized":
+				if err := trg.Start(); err != nil {
+					t.Fatal(err)
+				}
+				state = "started"
+			case "started":
+				go func() { stopped <- trg.Stop() }()
+				// Stop fails the readiness probe before waiting for drainDelay
+				deadline := time.Now().Add(time.Second)
+				for trg.readiness() == nil && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+				state = "draining"
+			case "draining":
+				if err := <-stopped; err != nil {
+					t.Fatal(err)
+				}
+				state = "stopped"
+			}
+		}
+
+		if test.state == "draining" {
+			select {
+			case <-stopped:
+				t.Fatal("Stop returned before drainDelay")
+			default:
+			}
+		}
+
+		status, body := probe(t, trg, test.path)
+		if status != test.status || (test.reason != "" && body["reason"] != test.reason) {
+			t.Errorf("%s %s: got %d %v, want %d %s", test.state, test.path, status, body, test.status, test.reason)
+		}
+	}
+}
+
+func TestVersionProbe(t *testing.T) {
+	trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, nil), newTestHandler("user", replyData(nil)))
+
+	status, body := probe(t, trg, versionPath)
+	if status != http.StatusOK {
+		t.Fatalf("got status %d", status)
+	}
+	if body["schemaHash"] != schemaHash(trg.currentSchema("")) || body["serverInstanceId"] != trg.server.InstanceID() {
+		t.Errorf("unexpected version %v", body)
+	}
+}
+
+func TestSchemaHash(t *testing.T) {
+	hashOf := func(types, schema string) string {
+		trg := newTestTrigger(t, testSettings(t, types, schema, nil), newTestHandler("user", replyData(nil)))
+		return schemaHash(trg.currentSchema(""))
+	}
+
+	base := hashOf(testTypes, testSchema)
+
+	tests := []struct {
+		name   string
+		types  string
+		schema string
+		same   bool
+	}{
+		{"rebuilt", testTypes, testSchema, true},
+		{"reordered", `[{"Name":"user","Fields":{"name":{"Type":"graphql.String"},"id":{"Type":"graphql.String"}}}]`, testSchema, true},
+		{"field added", `[{"Name":"user","Fields":{"id":{"Type":"graphql.String"},"name":{"Type":"graphql.String"},"email":{"Type":"graphql.String"}}}]`, testSchema, false},
+		{"field type", `[{"Name":"user","Fields":{"id":{"Type":"graphql.Int"},"name":{"Type":"graphql.String"}}}]`, testSchema, false},
+		{"argument type", testTypes, `{"Query":{"Name":"Query","Fields":{"user":{"Type":"user","Args":{"id":{"Type":"graphql.Int"}}}}}}`, false},
+	}
+
+	for _, test := range tests {
+		if same := hashOf(test.types, test.schema) == base; same != test.same {
+			t.Errorf("%s: got same hash %v, want %v", test.name, same, test.same)
+		}
+	}
+}
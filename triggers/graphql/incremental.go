@@ -0,0 +1,644 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Incremental delivery with @defer and @stream, following the format of
+// https://github.com/graphql/graphql-wg/blob/main/rfcs/DeferStream.md as of deferSpec=20220824
+
+const (
+	mediaTypeMultipart = "multipart/mixed"
+
+	// multipartBoundary separates the parts of an incremental response
+	multipartBoundary = "-"
+)
+
+var deferDirective = graphql.NewDirective(graphql.DirectiveConfig{
+	Name:        "defer",
+	Description: "Delivers the fragment after the rest of the response.",
+	Locations:   []string{graphql.DirectiveLocationFragmentSpread, graphql.DirectiveLocationInlineFragment},
+	Args: graphql.FieldConfigArgument{
+		"label": &graphql.ArgumentConfig{Type: graphql.String},
+		"if":    &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: true},
+	},
+})
+
+var streamDirective = graphql.NewDirective(graphql.DirectiveConfig{
+	Name:        "stream",
+	Description: "Delivers the items of the list after the first initialCount items.",
+	Locations:   []string{graphql.DirectiveLocationField},
+	Args: graphql.FieldConfigArgument{
+		"label":        &graphql.ArgumentConfig{Type: graphql.String},
+		"if":           &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: true},
+		"initialCount": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+	},
+})
+
+// isIncrementalDirective reports whether the directive is applied by the trigger to deliver the response
+func isIncrementalDirective(name string) bool {
+	return name == deferDirective.Name || name == streamDirective.Name
+}
+
+// acceptsMultipart reports whether the client accepts incremental responses
+func acceptsMultipart(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != mediaTypeMultipart {
+			continue
+		}
+
+		if val, ok := params["q"]; ok {
+			if q, err := strconv.ParseFloat(val, 64); err != nil || q <= 0 {
+				continue
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// deferredFragment is a fragment marked with @defer, parents are the fields and fragments it is nested in
+type deferredFragment struct {
+	label    string
+	parents  []ast.Selection
+	fragment *ast.InlineFragment
+}
+
+// streamedField is a list field marked with @stream, parents are the fields and fragments it is nested in
+type streamedField struct {
+	label        string
+	initialCount int
+	parents      []ast.Selection
+	field        *ast.Field
+}
+
+// incrementalPlan splits an operation into the initial operation, without the deferred fragments, and the deferred
+// fragments which are executed separately
+type incrementalPlan struct {
+	variables map[string]interface{}
+	fragments map[string]*ast.FragmentDefinition
+
+	operation *ast.OperationDefinition
+	initial   *ast.Document
+	defers    []*deferredFragment
+	streams   []*streamedField
+}
+
+// incrementalPlanFor returns the plan of a validated query using @defer or @stream. nil is returned for other
+// requests.
+func incrementalPlanFor(doc *ast.Document, req *graphQLRequest) *incrementalPlan {
+	if !strings.Contains(req.Query, "@"+deferDirective.Name) && !strings.Contains(req.Query, "@"+streamDirective.Name) {
+		return nil
+	}
+
+	plan, err := newIncrementalPlan(doc, req.OperationName, req.Variables)
+	if err != nil || plan.operation.Operation != ast.OperationTypeQuery || !plan.incremental() {
+		return nil
+	}
+
+	return plan
+}
+
+// newIncrementalPlan plans the incremental delivery of the operation of a validated document
+func newIncrementalPlan(doc *ast.Document, operationName string, variables map[string]interface{}) (*incrementalPlan, error) {
+	plan := &incrementalPlan{
+		variables: variables,
+		fragments: make(map[string]*ast.FragmentDefinition),
+	}
+
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			plan.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				plan.operation = def
+			}
+		}
+	}
+
+	if plan.operation == nil {
+		return nil, fmt.Errorf("unknown operation '%s'", operationName)
+	}
+
+	op := *plan.operation
+	op.SelectionSet = plan.split(plan.operation.SelectionSet, nil)
+	plan.initial = plan.document(&op)
+
+	return plan, nil
+}
+
+// incremental reports whether part of the response is delivered incrementally
+func (plan *incrementalPlan) incremental() bool {
+	return len(plan.defers) > 0 || len(plan.streams) > 0
+}
+
+// document builds a document holding the operation and the fragment definitions of the request
+func (plan *incrementalPlan) document(op *ast.OperationDefinition) *ast.Document {
+	definitions := []ast.Node{op}
+	for _, def := range plan.fragments {
+		definitions = append(definitions, def)
+	}
+
+	return ast.NewDocument(&ast.Document{Definitions: definitions})
+}
+
+// split removes the deferred fragments from the selection set, and records them along with the streamed fields.
+// Fragment spreads are inlined, so that the fragments deferred within them get their own parents.
+func (plan *incrementalPlan) split(set *ast.SelectionSet, parents []ast.Selection) *ast.SelectionSet {
+	if set == nil {
+		return nil
+	}
+
+	var selections []ast.Selection
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			field := *selection
+			if directive := plan.active(selection.Directives, streamDirective.Name); directive != nil {
+				initialCount, _ := data.CoerceToInteger(plan.argument(directive, "initialCount"))
+				plan.streams = append(plan.streams, &streamedField{
+					label:        plan.label(directive),
+					initialCount: maxInt(initialCount, 0),
+					parents:      parents,
+					field:        selection,
+				})
+			}
+			field.SelectionSet = plan.split(selection.SelectionSet, appendSelection(parents, selection))
+			selections = append(selections, &field)
+		case *ast.InlineFragment:
+			if directive := plan.active(selection.Directives, deferDirective.Name); directive != nil {
+				fragment := *selection
+				fragment.Directives = withoutDirective(selection.Directives, deferDirective.Name)
+				plan.defers = append(plan.defers, &deferredFragment{label: plan.label(directive), parents: parents, fragment: &fragment})
+				continue
+			}
+			fragment := *selection
+			fragment.SelectionSet = plan.split(selection.SelectionSet, appendSelection(parents, selection))
+			selections = append(selections, &fragment)
+		case *ast.FragmentSpread:
+			def, ok := plan.fragments[selection.Name.Value]
+			if !ok {
+				selections = append(selections, selection)
+				continue
+			}
+			fragment := ast.NewInlineFragment(&ast.InlineFragment{
+				TypeCondition: def.TypeCondition,
+				Directives:    withoutDirective(selection.Directives, deferDirective.Name),
+				SelectionSet:  def.SelectionSet,
+			})
+			if directive := plan.active(selection.Directives, deferDirective.Name); directive != nil {
+				plan.defers = append(plan.defers, &deferredFragment{label: plan.label(directive), parents: parents, fragment: fragment})
+				continue
+			}
+			fragment.SelectionSet = plan.split(def.SelectionSet, appendSelection(parents, fragment))
+			selections = append(selections, fragment)
+		}
+	}
+
+	return ast.NewSelectionSet(&ast.SelectionSet{Selections: selections})
+}
+
+// active returns the named directive, unless it is missing or disabled by its if argument
+func (plan *incrementalPlan) active(directives []*ast.Directive, name string) *ast.Directive {
+	for _, directive := range directives {
+		if directive.Name.Value != name {
+			continue
+		}
+		if enabled, ok := plan.argument(directive, "if").(bool); ok && !enabled {
+			return nil
+		}
+		return directive
+	}
+
+	return nil
+}
+
+func (plan *incrementalPlan) argument(directive *ast.Directive, name string) interface{} {
+	for _, arg := range directive.Arguments {
+		if arg.Name.Value != name {
+			continue
+		}
+		if variable, ok := arg.Value.(*ast.Variable); ok {
+			return plan.variables[variable.Name.Value]
+		}
+		return valueFromAST(arg.Value)
+	}
+
+	return nil
+}
+
+func (plan *incrementalPlan) label(directive *ast.Directive) string {
+	label, _ := plan.argument(directive, "label").(string)
+	return label
+}
+
+// deferredDocument builds the document executing a deferred fragment, nested in copies of its parents
+func (plan *incrementalPlan) deferredDocument(deferred *deferredFragment) *ast.Document {
+	var selection ast.Selection = deferred.fragment
+
+	for i := len(deferred.parents) - 1; i >= 0; i-- {
+		set := ast.NewSelectionSet(&ast.SelectionSet{Selections: []ast.Selection{selection}})
+		switch parent := deferred.parents[i].(type) {
+		case *ast.Field:
+			field := *parent
+			field.SelectionSet = set
+			selection = &field
+		case *ast.InlineFragment:
+			fragment := *parent
+			fragment.SelectionSet = set
+			selection = &fragment
+		}
+	}
+
+	op := *plan.operation
+	op.SelectionSet = ast.NewSelectionSet(&ast.SelectionSet{Selections: []ast.Selection{selection}})
+
+	return plan.document(&op)
+}
+
+func appendSelection(parents []ast.Selection, selection ast.Selection) []ast.Selection {
+	return append(append([]ast.Selection{}, parents...), selection)
+}
+
+func withoutDirective(directives []*ast.Directive, name string) []*ast.Directive {
+	var remaining []*ast.Directive
+	for _, directive := range directives {
+		if directive.Name.Value != name {
+			remaining = append(remaining, directive)
+		}
+	}
+
+	return remaining
+}
+
+// responseKey is the key of a field in the response, its alias or name
+func responseKey(field *ast.Field) string {
+	if field.Alias != nil {
+		return field.Alias.Value
+	}
+	return field.Name.Value
+}
+
+// walkResponse calls fn with each object the parents lead to in the response data, along with its path. Lists are
+// walked item by item.
+func walkResponse(value interface{}, parents []ast.Selection, path []interface{}, fn func(obj map[string]interface{}, path []interface{})) {
+	if items, ok := value.([]interface{}); ok {
+		for i, item := range items {
+			walkResponse(item, parents, append(append([]interface{}{}, path...), i), fn)
+		}
+		return
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if len(parents) == 0 {
+		fn(obj, path)
+		return
+	}
+
+	field, ok := parents[0].(*ast.Field)
+	if !ok {
+		walkResponse(obj, parents[1:], path, fn)
+		return
+	}
+
+	key := responseKey(field)
+	walkResponse(obj[key], parents[1:], append(append([]interface{}{}, path...), key), fn)
+}
+
+// incrementalResult is a deferred fragment or a streamed list slice, delivered after the initial response
+type incrementalResult struct {
+	Data   map[string]interface{}     `json:"data,omitempty"`
+	Items  []interface{}              `json:"items,omitempty"`
+	Path   []interface{}              `json:"path"`
+	Label  string                     `json:"label,omitempty"`
+	Errors []gqlerrors.FormattedError `json:"errors,omitempty"`
+}
+
+// subsequentPayload is a part of the response following the initial response
+type subsequentPayload struct {
+	Incremental []*incrementalResult   `json:"incremental,omitempty"`
+	HasNext     bool                   `json:"hasNext"`
+	Extensions  map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// streamResults truncates the streamed lists of the initial data to their initialCount, the remaining items are
+// returned to be delivered after the initial response
+func (plan *incrementalPlan) streamResults(initialData interface{}) []*incrementalResult {
+	var results []*incrementalResult
+
+	for _, streamed := range plan.streams {
+		key := responseKey(streamed.field)
+
+		walkResponse(initialData, streamed.parents, []interface{}{}, func(obj map[string]interface{}, path []interface{}) {
+			items, ok := obj[key].([]interface{})
+			if !ok || len(items) <= streamed.initialCount {
+				return
+			}
+
+			obj[key] = items[:streamed.initialCount]
+			results = append(results, &incrementalResult{
+				Items: items[streamed.initialCount:],
+				Path:  append(append([]interface{}{}, path...), key, streamed.initialCount),
+				Label: streamed.label,
+			})
+		})
+	}
+
+	return results
+}
+
+// deferredResults converts the result of a deferred fragment execution to a result for each object the fragment
+// applies to. Errors raised for the parents of the fragment were already reported by the initial response.
+func deferredResults(deferred *deferredFragment, result *graphql.Result) []*incrementalResult {
+	var results []*incrementalResult
+
+	walkResponse(result.Data, deferred.parents, []interface{}{}, func(obj map[string]interface{}, path []interface{}) {
+		if len(obj) == 0 {
+			// The type condition of the fragment does not match the object
+			return
+		}
+
+		results = append(results, &incrementalResult{Data: obj, Path: path, Label: deferred.label})
+	})
+
+	for _, err := range result.Errors {
+		for _, res := range results {
+			if hasPathPrefix(err.Path, res.Path) {
+				res.Errors = append(res.Errors, err)
+				break
+			}
+		}
+	}
+
+	return results
+}
+
+func hasPathPrefix(path, prefix []interface{}) bool {
+	if len(path) <= len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if fmt.Sprint(path[i]) != fmt.Sprint(prefix[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// multipartWriter writes the parts of an incremental response, flushing each one to the client
+type multipartWriter struct {
+	w http.ResponseWriter
+}
+
+func newMultipartWriter(w http.ResponseWriter, status int) *multipartWriter {
+	w.Header().Set("Content-Type", fmt.Sprintf("%s; boundary=\"%s\"; deferSpec=20220824", mediaTypeMultipart, multipartBoundary))
+	w.WriteHeader(status)
+
+	return &multipartWriter{w: w}
+}
+
+func (mw *multipartWriter) writePart(payload interface{}) error {
+	if _, err := io.WriteString(mw.w, "\r\n--"+multipartBoundary+"\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"); err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(mw.w).Encode(payload); err != nil {
+		return err
+	}
+
+	if flusher, ok := mw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+func (mw *multipartWriter) close() error {
+	_, err := io.WriteString(mw.w, "\r\n--"+multipartBoundary+"--\r\n")
+	return err
+}
+
+// writeSinglePart writes the result of a query which is not incremental as a multipart response of a single part
+func writeSinglePart(w http.ResponseWriter, status int, result *graphql.Result) {
+	part := map[string]interface{}{
+		"data":    result.Data,
+		"hasNext": false,
+	}
+	if len(result.Errors) > 0 {
+		part["errors"] = result.Errors
+	}
+	if len(result.Extensions) > 0 {
+		part["extensions"] = result.Extensions
+	}
+
+	mw := newMultipartWriter(w, status)
+	if err := mw.writePart(part); err != nil {
+		log.Errorf("Unable to write the incremental response: %v", err)
+		return
+	}
+	if err := mw.close(); err != nil {
+		log.Errorf("Unable to write the incremental response: %v", err)
+	}
+}
+
+// serveIncremental executes the initial operation, then the deferred fragments concurrently, and writes the initial
+// response followed by the streamed items and the deferred fragments as they complete. The handlers of the deferred
+// fragments see the request context stored by the initial operation. finish is called once all the operations have
+// completed, and returns the extensions of the last part.
+func (t *GraphQLTrigger) serveIncremental(ctx context.Context, w http.ResponseWriter, schema graphql.Schema, plan *incrementalPlan, operationName string, finish func() map[string]interface{}) {
+
+	// The operations share the replies of the handlers, so that the parents of the deferred fragments are not
+	// resolved again, and the request context
+	ctx = context.WithValue(ctx, handlerCacheKey, newHandlerCache())
+	store := newRequestStore()
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        schema,
+		AST:           plan.initial,
+		OperationName: operationName,
+		Args:          plan.variables,
+		Context:       withRequestContext(ctx, store, plan.initial, operationName, plan.variables),
+	})
+	restoreExtensions(result)
+
+	// The deferred fragments start from the request context stored by the initial operation
+	deferred := make(chan []*incrementalResult, len(plan.defers))
+	for _, d := range plan.defers {
+		doc := plan.deferredDocument(d)
+		deferredCtx := withRequestContext(ctx, store, doc, operationName, plan.variables)
+
+		go func(d *deferredFragment) {
+			result := graphql.Execute(graphql.ExecuteParams{
+				Schema:        schema,
+				AST:           doc,
+				OperationName: operationName,
+				Args:          plan.variables,
+				Context:       deferredCtx,
+			})
+			restoreExtensions(result)
+			deferred <- deferredResults(d, result)
+		}(d)
+	}
+
+	streamed := plan.streamResults(result.Data)
+	pending := len(plan.defers)
+
+	status := http.StatusOK
+	if fieldStatus := responseStatus(t.statusPolicy, result); fieldStatus != 0 {
+		status = fieldStatus
+	}
+
+	initial := map[string]interface{}{
+		"data":    result.Data,
+		"hasNext": len(streamed) > 0 || pending > 0,
+	}
+	if len(result.Errors) > 0 {
+		initial["errors"] = result.Errors
+	}
+
+	mw := newMultipartWriter(w, status)
+	parts := []interface{}{initial}
+
+	if len(streamed) > 0 {
+		parts = append(parts, &subsequentPayload{Incremental: streamed, HasNext: pending > 0})
+	}
+
+	for {
+		if pending == 0 {
+			if extensions := finish(); len(extensions) > 0 {
+				switch part := parts[len(parts)-1].(type) {
+				case map[string]interface{}:
+					part["extensions"] = extensions
+				case *subsequentPayload:
+					part.Extensions = extensions
+				}
+			}
+		}
+
+		for _, part := range parts {
+			if err := mw.writePart(part); err != nil {
+				log.Errorf("Unable to write the incremental response: %v", err)
+				return
+			}
+		}
+
+		if pending == 0 {
+			break
+		}
+
+		results := <-deferred
+		pending--
+		parts = []interface{}{&subsequentPayload{Incremental: results, HasNext: pending > 0}}
+	}
+
+	if err := mw.close(); err != nil {
+		log.Errorf("Unable to write the incremental response: %v", err)
+	}
+}
+
+// handlerCacheKey is the context key of the handler cache of a request
+const handlerCacheKey contextKey = "handlerCache"
+
+// handlerCache holds the replies of the handlers for a request, keyed by handler and trigger data
+type handlerCache struct {
+	mu      sync.Mutex
+	replies map[string]*cachedReply
+}
+
+type cachedReply struct {
+	done    chan struct{}
+	results map[string]*data.Attribute
+	err     error
+}
+
+func newHandlerCache() *handlerCache {
+	return &handlerCache{replies: make(map[string]*cachedReply)}
+}
+
+// handle calls the handler, unless the request has a handler cache already holding, or waiting for, its reply to the
+// same trigger data
+func handle(ctx context.Context, handler *trigger.Handler, triggerData map[string]interface{}) (map[string]*data.Attribute, error) {
+	var cache *handlerCache
+	if ctx != nil {
+		cache, _ = ctx.Value(handlerCacheKey).(*handlerCache)
+	}
+	view := requestViewFromContext(ctx)
+
+	key, err := json.Marshal(triggerData)
+	if cache == nil || err != nil {
+		return callHandler(ctx, handler, triggerData, view)
+	}
+
+	cacheKey := fmt.Sprintf("%p/%s", handler, key)
+
+	cache.mu.Lock()
+	reply, ok := cache.replies[cacheKey]
+	if !ok {
+		reply = &cachedReply{done: make(chan struct{})}
+		cache.replies[cacheKey] = reply
+	}
+	cache.mu.Unlock()
+
+	if ok {
+		<-reply.done
+		return reply.results, reply.err
+	}
+
+	reply.results, reply.err = callHandler(ctx, handler, triggerData, view)
+	close(reply.done)
+
+	return reply.results, reply.err
+}
+
+// callHandler calls the handler with the context of the field and the request context visible to it, and stores the
+// request context of its reply. The reply of a handler whose field already failed, e.g. timed out, is not stored.
+func callHandler(ctx context.Context, handler *trigger.Handler, triggerData map[string]interface{}, view *requestView) (map[string]*data.Attribute, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if view == nil {
+		return handler.Handle(ctx, triggerData)
+	}
+
+	triggerData["requestContext"] = view.visible()
+
+	results, err := handler.Handle(ctx, triggerData)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.Err() != nil {
+		return results, nil
+	}
+
+	if err := view.store(results); err != nil {
+		return nil, fmt.Errorf("invalid requestContext reply: %v", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,37 @@
+package graphql
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMultipartOnly(t *testing.T) {
+	trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, nil),
+		newTestHandler("user", replyData(map[string]interface{}{"id": "1", "name": "Matt"})))
+
+	// Clients accepting only multipart/mixed get a multipart response, even without @defer
+	tests := []struct {
+		query string
+		parts int
+	}{
+		{`{ user(id: "1") { id ... @defer { name } } }`, 2},
+		{`{ user(id: "1") { id name } }`, 1},
+	}
+
+	for _, test := range tests {
+		w := postQuery(trg, "multipart/mixed;deferSpec=20220824", test.query)
+		if w.Code != http.StatusOK {
+			t.Errorf("query %s: unexpected response %d: %s", test.query, w.Code, w.Body)
+			continue
+		}
+
+		body := w.Body.String()
+		if parts := strings.Count(body, "Content-Type: application/json"); parts != test.parts {
+			t.Errorf("query %s: expected %d parts, got %s", test.query, test.parts, body)
+		}
+		if !strings.Contains(body, `"name":"Matt"`) || !strings.Contains(body, `"hasNext":false`) {
+			t.Errorf("query %s: unexpected response %s", test.query, body)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+)
+
+// configureLimits applies the timeout & size settings of the trigger to its server. Requests are not bounded unless
+// the limits are set, as before they were introduced.
+func (t *GraphQLTrigger) configureLimits() error {
+	timeouts := []struct {
+		setting string
+		dst     *time.Duration
+	}{
+		{"readTimeout", &t.server.ReadTimeout},
+		{"writeTimeout", &t.server.WriteTimeout},
+		{"idleTimeout", &t.server.IdleTimeout},
+	}
+
+	for _, timeout := range timeouts {
+		var err error
+		if *timeout.dst, err = parseTimeout(timeout.setting, t.config.Settings[timeout.setting]); err != nil {
+			return err
+		}
+	}
+
+	maxHeaderBytes, err := parseLimit("maxHeaderBytes", t.config.Settings["maxHeaderBytes"], 0)
+	if err != nil {
+		return err
+	}
+	t.server.MaxHeaderBytes = int(maxHeaderBytes)
+
+	if t.maxBodySize, err = parseLimit("maxBodySize", t.config.Settings["maxBodySize"], 0); err != nil {
+		return err
+	}
+
+	maxQueryLength, err := parseLimit("maxQueryLength", t.config.Settings["maxQueryLength"], 0)
+	if err != nil {
+		return err
+	}
+	t.maxQueryLength = int(maxQueryLength)
+
+	return nil
+}
+
+// parseLimit parses a size setting, def is returned when it is not set
+func parseLimit(setting string, val interface{}, def int64) (int64, error) {
+	if val == nil || val == "" {
+		return def, nil
+	}
+
+	n, err := data.CoerceToInteger(val)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s '%v'", setting, val)
+	}
+
+	return int64(n), nil
+}
+
+// isBodyTooLarge reports whether err was raised by reading more than maxBodySize bytes from a request body, which is
+// limited by http.MaxBytesReader
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
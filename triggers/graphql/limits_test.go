@@ -0,0 +1,70 @@
+package graphql
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+)
+
+func TestConfigureLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    map[string]interface{}
+		readTimeout time.Duration
+		idleTimeout time.Duration
+		maxBodySize int64
+		invalid     bool
+	}{
+		// Requests are not bounded by default
+		{"defaults", nil, 0, 0, 0, false},
+		{"set", map[string]interface{}{"readTimeout": "30s", "idleTimeout": "2m", "maxBodySize": 1048576}, 30 * time.Second, 2 * time.Minute, 1 << 20, false},
+		{"lifted", map[string]interface{}{"readTimeout": "0", "maxBodySize": 0}, 0, 0, 0, false},
+		{"invalid timeout", map[string]interface{}{"readTimeout": "soon"}, 0, 0, 0, true},
+		{"invalid size", map[string]interface{}{"maxBodySize": -1}, 0, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		trg := &GraphQLTrigger{config: &trigger.Config{Settings: test.settings}, server: NewServer(":0", nil)}
+
+		err := trg.configureLimits()
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if trg.server.ReadTimeout != test.readTimeout || trg.server.IdleTimeout != test.idleTimeout || trg.maxBodySize != test.maxBodySize {
+			t.Errorf("%s: got read %v, idle %v, body %d", test.name, trg.server.ReadTimeout, trg.server.IdleTimeout, trg.maxBodySize)
+		}
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	tests := []struct {
+		maxBodySize interface{}
+		status      int
+	}{
+		{64, http.StatusRequestEntityTooLarge},
+		{nil, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		extra := map[string]interface{}{}
+		if test.maxBodySize != nil {
+			extra["maxBodySize"] = test.maxBodySize
+		}
+		trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, extra), newTestHandler("user", replyData(nil)))
+
+		w := postQuery(trg, mediaTypeJSON, `{ user(id: "a long enough id to exceed the limit of the body") { name } }`)
+		if w.Code != test.status {
+			t.Errorf("maxBodySize %v: got status %d, want %d: %s", test.maxBodySize, w.Code, test.status, w.Body)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package graphql
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+)
+
+// maskingResolver replaces the unexpected errors of a resolver, such as the errors of flows, with a generic error
+// holding a correlation ID, and logs the original error under that ID. Errors of invalid arguments, and errors which
+// handlers flagged as safe, are passed to clients.
+func maskingResolver(handlerID string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+
+	return func(p graphql.ResolveParams) (value interface{}, err error) {
+
+		defer func() {
+			if r := recover(); r != nil {
+				value, err = nil, maskError(handlerID, p.Info.FieldName, fmt.Errorf("panic: %v", r))
+			}
+		}()
+
+		if value, err = resolve(p); err != nil {
+			err = maskError(handlerID, p.Info.FieldName, err)
+		}
+
+		return value, err
+	}
+}
+
+// maskError returns the error passed to clients in place of an error of a resolver, the status of the error is kept
+func maskError(handlerID, fieldName string, err error) error {
+	status := http.StatusInternalServerError
+	switch e := err.(type) {
+	case *argumentError:
+		return err
+	case *statusError:
+		if e.safe {
+			return err
+		}
+		status = e.status
+	}
+
+	id := correlationID()
+	log.Errorf("Error '%s' of handler '%s' resolving field '%s': %v", id, handlerID, fieldName, err)
+
+	return &statusError{
+		status:        status,
+		message:       fmt.Sprintf("%s, correlation ID '%s'", http.StatusText(status), id),
+		correlationID: id,
+	}
+}
+
+func correlationID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
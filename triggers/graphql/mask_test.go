@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+)
+
+func TestMaskErrors(t *testing.T) {
+	var queries []string
+	remote := newRemoteServer(t, &queries)
+	defer remote.Close()
+
+	tests := []struct {
+		name    string
+		reply   map[string]interface{}
+		remote  bool
+		message string
+		leak    string
+	}{
+		{"unsafe", map[string]interface{}{"error": "dial tcp 10.0.0.1:5432: connection refused"}, false, "", "10.0.0.1"},
+		{"safe", map[string]interface{}{"status": 404, "error": "no user '1'", "safe": true}, false, "no user '1'", ""},
+		// The local user field has no handler, it is served by the remote endpoint, which is down
+		{"remote", nil, true, "", remote.URL},
+	}
+
+	for _, test := range tests {
+		extra := map[string]interface{}{"maskErrors": true}
+		var handlers []*trigger.Handler
+		if test.remote {
+			extra["remoteEndpoint"] = remote.URL
+			extra["remoteHeaders"] = map[string]interface{}{"Authorization": "Bearer token"}
+		} else {
+			handlers = append(handlers, newTestHandler("user", replyWith(test.reply)))
+		}
+		trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, extra), handlers...)
+
+		if test.remote {
+			remote.Close()
+		}
+
+		w := postQuery(trg, mediaTypeJSON, `{ user(id: "1") { name } }`)
+		result := decodeResult(t, w)
+
+		errs, _ := result["errors"].([]interface{})
+		if len(errs) != 1 {
+			t.Errorf("%s: unexpected response %d: %s", test.name, w.Code, w.Body)
+			continue
+		}
+		if test.leak != "" && strings.Contains(w.Body.String(), test.leak) {
+			t.Errorf("%s: the error is not masked: %s", test.name, w.Body)
+		}
+
+		err := errs[0].(map[string]interface{})
+		extensions, _ := err["extensions"].(map[string]interface{})
+		if test.message != "" {
+			if err["message"] != test.message {
+				t.Errorf("%s: got message %v, want %s", test.name, err["message"], test.message)
+			}
+		} else if extensions["correlationId"] == nil {
+			t.Errorf("%s: no correlation ID in error %v", test.name, err)
+		}
+	}
+}
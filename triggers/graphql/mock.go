@@ -0,0 +1,126 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// mockListLength is the number of items generated for lists
+	mockListLength = 2
+
+	// mockMaxDepth limits the generation of values for recursive types
+	mockMaxDepth = 5
+)
+
+// readMockFixtures reads the fixtures file, which maps field paths such as user or user.name to their value
+func readMockFixtures(path string) (map[string]interface{}, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read mock fixtures file '%s': %v", path, err)
+	}
+
+	fixtures := make(map[string]interface{})
+	if err := json.Unmarshal(content, &fixtures); err != nil {
+		return nil, fmt.Errorf("unable to parse mock fixtures file '%s': %v", path, err)
+	}
+
+	return fixtures, nil
+}
+
+// mockResolver resolves a field which has no handler with generated data matching its type. For connection fields,
+// listType is the type of the list the connection is built from.
+func mockResolver(fixtures map[string]interface{}, listType graphql.Output) graphql.FieldResolveFn {
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		if listType == nil {
+			return mockValue(p.Info.ReturnType, p.Info.FieldName, fixtures, &p.Info.Schema, 0), nil
+		}
+
+		_, page, err := newPagination(p.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		items, _ := mockValue(listType, p.Info.FieldName, fixtures, &p.Info.Schema, 0).([]interface{})
+		return connectionFromSlice(items, page, 0, len(items)), nil
+	}
+}
+
+// mockValue generates a value for the type, unless the fixtures hold a value for the path
+func mockValue(typ graphql.Type, path string, fixtures map[string]interface{}, schema *graphql.Schema, depth int) interface{} {
+	if value, ok := fixtures[path]; ok {
+		return value
+	}
+
+	if depth > mockMaxDepth {
+		return nil
+	}
+
+	name := path[strings.LastIndex(path, ".")+1:]
+
+	switch typ := typ.(type) {
+	case *graphql.NonNull:
+		return mockValue(typ.OfType, path, fixtures, schema, depth)
+	case *graphql.List:
+		items := make([]interface{}, mockListLength)
+		for i := range items {
+			items[i] = mockValue(typ.OfType, path, fixtures, schema, depth+1)
+		}
+		return items
+	case *graphql.Scalar:
+		switch typ {
+		case graphql.Int:
+			return 42
+		case graphql.Float:
+			return 4.2
+		case graphql.Boolean:
+			return true
+		}
+		return "mock " + name
+	case *graphql.Enum:
+		// The values of an enum are not kept in the order they are defined, the first by name is taken so that the
+		// generated value is stable
+		var first *graphql.EnumValueDefinition
+		for _, value := range typ.Values() {
+			if first == nil || value.Name < first.Name {
+				first = value
+			}
+		}
+		if first != nil {
+			return first.Value
+		}
+	case *graphql.Object:
+		obj := make(map[string]interface{})
+		for k, f := range typ.Fields() {
+			obj[k] = mockValue(f.Type, path+"."+k, fixtures, schema, depth+1)
+		}
+		return obj
+	case *graphql.Interface:
+		return mockAbstractValue(typ, path, fixtures, schema, depth)
+	case *graphql.Union:
+		return mockAbstractValue(typ, path, fixtures, schema, depth)
+	}
+
+	return nil
+}
+
+// mockAbstractValue generates a value for the first possible type of an interface or union
+func mockAbstractValue(typ graphql.Abstract, path string, fixtures map[string]interface{}, schema *graphql.Schema, depth int) interface{} {
+	possibleTypes := schema.PossibleTypes(typ)
+	if len(possibleTypes) == 0 {
+		return nil
+	}
+
+	obj, ok := mockValue(possibleTypes[0], path, fixtures, schema, depth).(map[string]interface{})
+	if ok {
+		obj["__typename"] = possibleTypes[0].Name()
+	}
+
+	return obj
+}
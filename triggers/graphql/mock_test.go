@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+)
+
+// mockTypes & mockSchema cover the kinds of values generated in mock mode
+const (
+	mockTypes = `[
+		{"Name":"role","Kind":"enum","Values":["ADMIN","GUEST"]},
+		{"Name":"node","Kind":"interface","Discriminator":"kind","Fields":{"id":{"Type":"graphql.String"}}},
+		{"Name":"user","Interfaces":["node"],"Fields":{"name":{"Type":"graphql.String"},"age":{"Type":"graphql.Int"},"score":{"Type":"graphql.Float"},"active":{"Type":"graphql.Boolean"},"role":{"Type":"role"}}},
+		{"Name":"result","Kind":"union","Types":["user"]}
+	]`
+	mockSchema = `{"Query":{"Name":"Query","Fields":{
+		"user":{"Type":"user","Args":{"id":{"Type":"graphql.String"}}},
+		"users":{"Type":"[user]","Connection":true},
+		"tags":{"Type":"[graphql.String]"},
+		"node":{"Type":"node"},
+		"search":{"Type":"result"}
+	}}}`
+)
+
+func TestMockValues(t *testing.T) {
+	trg := newTestTrigger(t, testSettings(t, mockTypes, mockSchema, map[string]interface{}{"mock": true}))
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ user(id: "1") { name age score active role } }`, `{"user":{"active":true,"age":42,"name":"mock name","role":"ADMIN","score":4.2}}`},
+		{`{ tags }`, `{"tags":["mock tags","mock tags"]}`},
+		{`{ node { __typename id } }`, `{"node":{"__typename":"user","id":"mock id"}}`},
+		{`{ search { __typename ... on user { name } } }`, `{"search":{"__typename":"user","name":"mock name"}}`},
+		{`{ users(first: 1) { totalCount edges { node { name } } pageInfo { hasNextPage } } }`, `{"users":{"edges":[{"node":{"name":"mock name"}}],"pageInfo":{"hasNextPage":true},"totalCount":2}}`},
+	}
+
+	for _, test := range tests {
+		result := decodeResult(t, postQuery(trg, mediaTypeJSON, test.query))
+		if result["errors"] != nil {
+			t.Errorf("query %s: unexpected errors %v", test.query, result["errors"])
+			continue
+		}
+
+		if got, _ := json.Marshal(result["data"]); string(got) != test.want {
+			t.Errorf("query %s: got %s, want %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestMockFixtures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixtures.json")
+	if err := ioutil.WriteFile(path, []byte(`{"user.name":"Matt","tags":["a","b","c"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	trg := newTestTrigger(t, testSettings(t, mockTypes, mockSchema, map[string]interface{}{"mock": true, "mockFixtures": path}))
+
+	result := decodeResult(t, postQuery(trg, mediaTypeJSON, `{ user(id: "1") { name age } tags }`))
+	want := map[string]interface{}{
+		"user": map[string]interface{}{"name": "Matt", "age": float64(42)},
+		"tags": []interface{}{"a", "b", "c"},
+	}
+	if !reflect.DeepEqual(result["data"], want) {
+		t.Errorf("got %v, want %v", result, want)
+	}
+
+	// A missing fixtures file fails the initialization
+	settings := testSettings(t, mockTypes, mockSchema, map[string]interface{}{"mock": true, "mockFixtures": filepath.Join(dir, "missing.json")})
+	trg = NewFactory(nil).New(&trigger.Config{Id: "test", Settings: settings}).(*GraphQLTrigger)
+	if err := trg.Initialize(&testInitContext{}); err == nil {
+		t.Error("expected an error for a missing fixtures file")
+	}
+}
+
+func TestMockRemoteField(t *testing.T) {
+	var queries []string
+	server := newRemoteServer(t, &queries)
+	defer server.Close()
+
+	settings := map[string]interface{}{
+		"mock":           true,
+		"remoteEndpoint": server.URL,
+		"remoteHeaders":  map[string]interface{}{"Authorization": "Bearer token"},
+	}
+
+	tests := []struct {
+		name    string
+		handler bool
+		want    string
+		remote  int
+	}{
+		// The remote field resolves the local field which has no handler, rather than mock data
+		{"remote", false, "Alice", 1},
+		// The local handler shadows the remote field
+		{"local", true, "Matt", 0},
+	}
+
+	for _, test := range tests {
+		var handlers []*trigger.Handler
+		if test.handler {
+			handlers = append(handlers, newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})))
+		}
+		trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, settings), handlers...)
+		queries = nil
+
+		result := decodeResult(t, postQuery(trg, mediaTypeJSON, `{ user(id: "1") { name } }`))
+		want := map[string]interface{}{"user": map[string]interface{}{"name": test.want}}
+		if !reflect.DeepEqual(result["data"], want) {
+			t.Errorf("%s: got %v, want %v", test.name, result, want)
+		}
+		if len(queries) != test.remote {
+			t.Errorf("%s: got %d remote queries, want %d", test.name, len(queries), test.remote)
+		}
+	}
+}
@@ -0,0 +1,610 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/kinds"
+	"github.com/graphql-go/graphql/language/printer"
+)
+
+const defaultRemoteTimeout = 30 * time.Second
+
+const introspectionQuery = `
+query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    types { ...FullType }
+  }
+}
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } }
+}`
+
+// remoteSchema is a remote GraphQL endpoint whose query fields are merged into the schema of the trigger
+type remoteSchema struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// configureRemote sets up the remote schema of the remoteEndpoint setting, if any
+func (t *GraphQLTrigger) configureRemote() error {
+	endpoint := t.config.GetSetting("remoteEndpoint")
+	if endpoint == "" {
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(t.config.GetSetting("remoteTimeout"))
+	if err != nil || timeout <= 0 {
+		timeout = defaultRemoteTimeout
+	}
+
+	headers, err := data.CoerceToParams(t.config.Settings["remoteHeaders"])
+	if err != nil {
+		return fmt.Errorf("invalid remoteHeaders: %v", err)
+	}
+
+	t.remote = newRemoteSchema(endpoint, headers, timeout)
+	return nil
+}
+
+func newRemoteSchema(endpoint string, headers map[string]string, timeout time.Duration) *remoteSchema {
+	return &remoteSchema{
+		endpoint: endpoint,
+		headers:  headers,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// remoteResponse is the response of the remote endpoint
+type remoteResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// execute sends a query to the remote endpoint
+func (rs *remoteSchema) execute(ctx context.Context, query string, variables map[string]interface{}) (*remoteResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", rs.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+
+	req.Header.Set("Content-Type", mediaTypeJSON)
+	req.Header.Set("Accept", mediaTypeJSON)
+	for k, v := range rs.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach remote GraphQL endpoint '%s': %v", rs.endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	result := &remoteResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("invalid response from remote GraphQL endpoint '%s' (status %d): %v", rs.endpoint, resp.StatusCode, err)
+	}
+
+	return result, nil
+}
+
+// introspection types, as returned by the introspection query
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+type introspectionInputValue struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Type        introspectionTypeRef `json:"type"`
+}
+
+type introspectionField struct {
+	Name              string                    `json:"name"`
+	Description       string                    `json:"description"`
+	Args              []introspectionInputValue `json:"args"`
+	Type              introspectionTypeRef      `json:"type"`
+	DeprecationReason string                    `json:"deprecationReason"`
+}
+
+type introspectionEnumValue struct {
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	DeprecationReason string `json:"deprecationReason"`
+}
+
+type introspectionType struct {
+	Kind          string                    `json:"kind"`
+	Name          string                    `json:"name"`
+	Description   string                    `json:"description"`
+	Fields        []introspectionField      `json:"fields"`
+	InputFields   []introspectionInputValue `json:"inputFields"`
+	Interfaces    []introspectionTypeRef    `json:"interfaces"`
+	EnumValues    []introspectionEnumValue  `json:"enumValues"`
+	PossibleTypes []introspectionTypeRef    `json:"possibleTypes"`
+}
+
+type introspectionSchema struct {
+	QueryType struct {
+		Name string `json:"name"`
+	} `json:"queryType"`
+	Types []introspectionType `json:"types"`
+}
+
+// introspect fetches the schema of the remote endpoint
+func (rs *remoteSchema) introspect() (*introspectionSchema, error) {
+	resp, err := rs.execute(context.Background(), introspectionQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("introspection of remote GraphQL endpoint '%s' failed: %s", rs.endpoint, resp.Errors[0].Message)
+	}
+
+	// Round trip through JSON to decode the generic response
+	content, err := json.Marshal(resp.Data["__schema"])
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &introspectionSchema{}
+	if err := json.Unmarshal(content, schema); err != nil {
+		return nil, fmt.Errorf("invalid introspection result from remote GraphQL endpoint '%s': %v", rs.endpoint, err)
+	}
+
+	return schema, nil
+}
+
+// buildFields introspects the remote endpoint and returns its query fields, which resolve by forwarding their
+// selection to the endpoint. The remote types are added to gqlObjects, a local type with the same name as a remote
+// type takes precedence over it.
+func (rs *remoteSchema) buildFields(gqlObjects map[string]graphql.Output) (graphql.Fields, error) {
+	schema, err := rs.introspect()
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]graphql.Type)
+	objects := make(map[string]*graphql.Object)
+
+	typeByName := func(name string) graphql.Type {
+		switch name {
+		case "String":
+			return graphql.String
+		case "Int":
+			return graphql.Int
+		case "Float":
+			return graphql.Float
+		case "Boolean":
+			return graphql.Boolean
+		case "ID":
+			return graphql.ID
+		}
+		if typ, ok := gqlObjects[name]; ok {
+			return typ
+		}
+		return types[name]
+	}
+
+	var typeFromRef func(ref *introspectionTypeRef) graphql.Type
+	typeFromRef = func(ref *introspectionTypeRef) graphql.Type {
+		if ref == nil {
+			return nil
+		}
+		switch ref.Kind {
+		case "NON_NULL":
+			return graphql.NewNonNull(typeFromRef(ref.OfType))
+		case "LIST":
+			return graphql.NewList(typeFromRef(ref.OfType))
+		}
+		return typeByName(ref.Name)
+	}
+
+	outputFromRef := func(ref *introspectionTypeRef) graphql.Output {
+		output, _ := typeFromRef(ref).(graphql.Output)
+		return output
+	}
+
+	inputFromRef := func(ref *introspectionTypeRef) graphql.Input {
+		input, _ := typeFromRef(ref).(graphql.Input)
+		return input
+	}
+
+	fieldsThunk := func(typ introspectionType) graphql.FieldsThunk {
+		return func() graphql.Fields {
+			fields := make(graphql.Fields)
+			for _, f := range typ.Fields {
+				args := make(graphql.FieldConfigArgument)
+				for _, arg := range f.Args {
+					args[arg.Name] = &graphql.ArgumentConfig{Type: inputFromRef(&arg.Type), Description: arg.Description}
+				}
+				fields[f.Name] = &graphql.Field{
+					Type:              outputFromRef(&f.Type),
+					Args:              args,
+					Description:       f.Description,
+					DeprecationReason: f.DeprecationReason,
+					Resolve:           directiveResolver(nil, resolveByResponseKey),
+				}
+			}
+			return fields
+		}
+	}
+
+	// Named types are created in dependency order, fields are resolved lazily as types may reference each other
+	for _, kind := range []string{"SCALAR", "ENUM", "INTERFACE", "OBJECT", "UNION", "INPUT_OBJECT"} {
+		for _, typ := range schema.Types {
+			if typ.Kind != kind || strings.HasPrefix(typ.Name, "__") || typeByName(typ.Name) != nil {
+				continue
+			}
+
+			switch typ.Kind {
+			case "SCALAR":
+				types[typ.Name] = graphql.NewScalar(graphql.ScalarConfig{
+					Name:         typ.Name,
+					Description:  typ.Description,
+					Serialize:    func(value interface{}) interface{} { return value },
+					ParseValue:   func(value interface{}) interface{} { return value },
+					ParseLiteral: valueFromAST,
+				})
+			case "ENUM":
+				values := make(graphql.EnumValueConfigMap)
+				for _, v := range typ.EnumValues {
+					values[v.Name] = &graphql.EnumValueConfig{Value: v.Name, Description: v.Description, DeprecationReason: v.DeprecationReason}
+				}
+				types[typ.Name] = graphql.NewEnum(graphql.EnumConfig{Name: typ.Name, Description: typ.Description, Values: values})
+			case "INTERFACE":
+				types[typ.Name] = graphql.NewInterface(graphql.InterfaceConfig{
+					Name:        typ.Name,
+					Description: typ.Description,
+					Fields:      fieldsThunk(typ),
+					ResolveType: resolveAbstractType(objects, ""),
+				})
+			case "OBJECT":
+				var interfaces []*graphql.Interface
+				for _, ref := range typ.Interfaces {
+					if iface, ok := typeByName(ref.Name).(*graphql.Interface); ok {
+						interfaces = append(interfaces, iface)
+					}
+				}
+				obj := graphql.NewObject(graphql.ObjectConfig{
+					Name:        typ.Name,
+					Description: typ.Description,
+					Fields:      fieldsThunk(typ),
+					Interfaces:  interfaces,
+				})
+				objects[typ.Name] = obj
+				types[typ.Name] = obj
+			case "UNION":
+				var members []*graphql.Object
+				for _, ref := range typ.PossibleTypes {
+					if obj, ok := typeByName(ref.Name).(*graphql.Object); ok {
+						members = append(members, obj)
+					}
+				}
+				types[typ.Name] = graphql.NewUnion(graphql.UnionConfig{
+					Name:        typ.Name,
+					Description: typ.Description,
+					Types:       members,
+					ResolveType: resolveAbstractType(objects, ""),
+				})
+			case "INPUT_OBJECT":
+				typ := typ
+				types[typ.Name] = graphql.NewInputObject(graphql.InputObjectConfig{
+					Name:        typ.Name,
+					Description: typ.Description,
+					Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+						fields := make(graphql.InputObjectConfigFieldMap)
+						for _, f := range typ.InputFields {
+							fields[f.Name] = &graphql.InputObjectFieldConfig{Type: inputFromRef(&f.Type), Description: f.Description}
+						}
+						return fields
+					}),
+				})
+			}
+		}
+	}
+
+	// Output types are registered with the schema, along with the local types
+	for name, typ := range types {
+		if output, ok := typ.(graphql.Output); ok {
+			gqlObjects[name] = output
+		}
+	}
+
+	queryType, ok := types[schema.QueryType.Name].(*graphql.Object)
+	if !ok {
+		return nil, fmt.Errorf("remote GraphQL endpoint '%s' has no query type", rs.endpoint)
+	}
+	// The remote query type itself isn't part of the local schema, only its fields are
+	delete(gqlObjects, schema.QueryType.Name)
+
+	fields := make(graphql.Fields)
+	for name, f := range queryType.Fields() {
+		args := make(graphql.FieldConfigArgument)
+		for _, arg := range f.Args {
+			args[arg.Name()] = &graphql.ArgumentConfig{Type: arg.Type, Description: arg.Description(), DefaultValue: arg.DefaultValue}
+		}
+
+		fields[name] = &graphql.Field{
+			Type:              f.Type,
+			Args:              args,
+			Description:       f.Description,
+			DeprecationReason: f.DeprecationReason,
+			Resolve:           directiveResolver(nil, rs.resolver()),
+		}
+	}
+
+	return fields, nil
+}
+
+// resolver forwards the selection of a remote query field to the remote endpoint
+func (rs *remoteSchema) resolver() graphql.FieldResolveFn {
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		query, variables := remoteQuery(p)
+
+		resp, err := rs.execute(p.Context, query, variables)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Errors) > 0 {
+			messages := make([]string, 0, len(resp.Errors))
+			for _, e := range resp.Errors {
+				messages = append(messages, e.Message)
+			}
+			return nil, errors.New(strings.Join(messages, "; "))
+		}
+
+		return resp.Data[fieldResponseKey(p)], nil
+	}
+}
+
+// resolveByResponseKey resolves a field of a remote object from the remote result, which holds the fields by the
+// alias they were selected with
+func resolveByResponseKey(p graphql.ResolveParams) (interface{}, error) {
+	if source, ok := p.Source.(map[string]interface{}); ok {
+		return source[fieldResponseKey(p)], nil
+	}
+	return graphql.DefaultResolveFn(p)
+}
+
+// fieldResponseKey returns the key of the resolved field in the result
+func fieldResponseKey(p graphql.ResolveParams) string {
+	if len(p.Info.FieldASTs) > 0 {
+		return responseKey(p.Info.FieldASTs[0])
+	}
+	return p.Info.FieldName
+}
+
+// remoteQuery builds the query forwarding the selection of a field, along with the variables it uses. Aliases are
+// kept, as the fields of the remote result are resolved by response key, and __typename is selected so that
+// interfaces and unions can be resolved.
+func remoteQuery(p graphql.ResolveParams) (string, map[string]interface{}) {
+	qr := &queryRewriter{
+		fragments: p.Info.Fragments,
+		used:      make(map[string]bool),
+		variables: make(map[string]bool),
+	}
+
+	var selections []ast.Selection
+	for _, field := range p.Info.FieldASTs {
+		selections = append(selections, qr.rewriteField(field))
+	}
+
+	definitions := []ast.Node{nil}
+
+	// Fragments may spread other fragments, the list grows while it is rewritten
+	for i := 0; i < len(qr.pending); i++ {
+		def := qr.pending[i]
+		definitions = append(definitions, ast.NewFragmentDefinition(&ast.FragmentDefinition{
+			Name:          def.Name,
+			TypeCondition: def.TypeCondition,
+			Directives:    qr.rewriteDirectives(def.Directives),
+			SelectionSet:  qr.rewriteSelectionSet(def.SelectionSet),
+		}))
+	}
+
+	variables := make(map[string]interface{})
+	var varDefs []*ast.VariableDefinition
+	if op, ok := p.Info.Operation.(*ast.OperationDefinition); ok {
+		for _, varDef := range op.VariableDefinitions {
+			name := varDef.Variable.Name.Value
+			if qr.variables[name] {
+				varDefs = append(varDefs, varDef)
+				if value, ok := p.Info.VariableValues[name]; ok {
+					variables[name] = value
+				}
+			}
+		}
+	}
+
+	definitions[0] = ast.NewOperationDefinition(&ast.OperationDefinition{
+		Operation:           ast.OperationTypeQuery,
+		VariableDefinitions: varDefs,
+		SelectionSet:        ast.NewSelectionSet(&ast.SelectionSet{Selections: selections}),
+	})
+
+	query, _ := printer.Print(ast.NewDocument(&ast.Document{Definitions: definitions})).(string)
+	return query, variables
+}
+
+// queryRewriter copies the selection of a field, collecting the fragments and variables it uses
+type queryRewriter struct {
+	fragments map[string]ast.Definition
+	used      map[string]bool
+	pending   []*ast.FragmentDefinition
+	variables map[string]bool
+}
+
+func (qr *queryRewriter) rewriteField(field *ast.Field) *ast.Field {
+	for _, arg := range field.Arguments {
+		qr.collectVariables(arg.Value)
+	}
+
+	return ast.NewField(&ast.Field{
+		Alias:        field.Alias,
+		Name:         field.Name,
+		Arguments:    field.Arguments,
+		Directives:   qr.rewriteDirectives(field.Directives),
+		SelectionSet: qr.rewriteSelectionSet(field.SelectionSet),
+	})
+}
+
+func (qr *queryRewriter) rewriteSelectionSet(set *ast.SelectionSet) *ast.SelectionSet {
+	if set == nil {
+		return nil
+	}
+
+	selections := []ast.Selection{
+		ast.NewField(&ast.Field{Name: ast.NewName(&ast.Name{Value: "__typename"})}),
+	}
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name.Value == "__typename" {
+				continue
+			}
+			selections = append(selections, qr.rewriteField(selection))
+		case *ast.InlineFragment:
+			selections = append(selections, ast.NewInlineFragment(&ast.InlineFragment{
+				TypeCondition: selection.TypeCondition,
+				Directives:    qr.rewriteDirectives(selection.Directives),
+				SelectionSet:  qr.rewriteSelectionSet(selection.SelectionSet),
+			}))
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			if !qr.used[name] {
+				qr.used[name] = true
+				if def, ok := qr.fragments[name].(*ast.FragmentDefinition); ok {
+					qr.pending = append(qr.pending, def)
+				}
+			}
+			selections = append(selections, ast.NewFragmentSpread(&ast.FragmentSpread{
+				Name:       selection.Name,
+				Directives: qr.rewriteDirectives(selection.Directives),
+			}))
+		}
+	}
+
+	return ast.NewSelectionSet(&ast.SelectionSet{Selections: selections})
+}
+
+// rewriteDirectives removes the directives which are applied by the trigger rather than the remote endpoint
+func (qr *queryRewriter) rewriteDirectives(directives []*ast.Directive) []*ast.Directive {
+	var remote []*ast.Directive
+
+	for _, directive := range directives {
+		if _, ok := lookupDirective(directive.Name.Value); ok || isIncrementalDirective(directive.Name.Value) {
+			continue
+		}
+
+		for _, arg := range directive.Arguments {
+			qr.collectVariables(arg.Value)
+		}
+		remote = append(remote, directive)
+	}
+
+	return remote
+}
+
+func (qr *queryRewriter) collectVariables(value ast.Value) {
+	switch value := value.(type) {
+	case *ast.Variable:
+		qr.variables[value.Name.Value] = true
+	case *ast.ListValue:
+		for _, v := range value.Values {
+			qr.collectVariables(v)
+		}
+	case *ast.ObjectValue:
+		for _, f := range value.Fields {
+			qr.collectVariables(f.Value)
+		}
+	}
+}
+
+// valueFromAST converts a literal to its value, for scalars of the remote schema
+func valueFromAST(valueAST ast.Value) interface{} {
+	switch valueAST.GetKind() {
+	case kinds.ListValue:
+		var values []interface{}
+		for _, v := range valueAST.(*ast.ListValue).Values {
+			values = append(values, valueFromAST(v))
+		}
+		return values
+	case kinds.ObjectValue:
+		obj := make(map[string]interface{})
+		for _, f := range valueAST.(*ast.ObjectValue).Fields {
+			obj[f.Name.Value] = valueFromAST(f.Value)
+		}
+		return obj
+	case kinds.IntValue, kinds.FloatValue:
+		number := json.Number(fmt.Sprint(valueAST.GetValue()))
+		if i, err := number.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := number.Float64()
+		return f
+	}
+
+	return valueAST.GetValue()
+}
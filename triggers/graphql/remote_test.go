@@ -0,0 +1,223 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// newRemoteServer serves a GraphQL schema of users over HTTP, the queries it receives are recorded
+func newRemoteServer(t *testing.T, queries *[]string) *httptest.Server {
+	var user *graphql.Object
+	user = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"name": &graphql.Field{Type: graphql.String},
+				"avatar": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{"size": &graphql.ArgumentConfig{Type: graphql.Int}},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return fmt.Sprintf("/avatars/%s/%v", p.Source.(map[string]interface{})["id"], p.Args["size"]), nil
+					},
+				},
+				"friends": &graphql.Field{
+					Type: graphql.NewList(user),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return []interface{}{map[string]interface{}{"id": "2", "name": "Bob"}}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"user": &graphql.Field{
+					Type: user,
+					Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return map[string]interface{}{"id": p.Args["id"], "name": "Alice"}, nil
+					},
+				},
+				"fail": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return nil, errors.New("remote failure")
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*queries = append(*queries, req.Query)
+
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: req.Query, VariableValues: req.Variables})
+		json.NewEncoder(w).Encode(result)
+	}))
+}
+
+// newRemoteTestSchema builds a local schema whose query fields are those of the remote server
+func newRemoteTestSchema(t *testing.T, endpoint string) graphql.Schema {
+	rs := newRemoteSchema(endpoint, map[string]string{"Authorization": "Bearer token"}, time.Second)
+
+	fields, err := rs.buildFields(make(map[string]graphql.Output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:      graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: fields}),
+		Directives: schemaDirectives(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestRemoteSchemaIntrospection(t *testing.T) {
+	var queries []string
+	server := newRemoteServer(t, &queries)
+	defer server.Close()
+
+	gqlObjects := make(map[string]graphql.Output)
+	fields, err := newRemoteSchema(server.URL, map[string]string{"Authorization": "Bearer token"}, time.Second).buildFields(gqlObjects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := fields["user"]; !ok {
+		t.Errorf("expected a user field, got %v", fields)
+	}
+	if _, ok := fields["user"].Args["id"]; !ok {
+		t.Errorf("expected the id argument of the user field")
+	}
+	if _, ok := gqlObjects["User"]; !ok {
+		t.Errorf("expected the remote User type")
+	}
+	if _, ok := gqlObjects["Query"]; ok {
+		t.Errorf("the remote query type should not be registered")
+	}
+
+	if _, err := newRemoteSchema(server.URL, nil, time.Second).buildFields(make(map[string]graphql.Output)); err == nil {
+		t.Errorf("expected introspection to fail without the headers")
+	}
+}
+
+func TestRemoteSchemaQueries(t *testing.T) {
+	var queries []string
+	server := newRemoteServer(t, &queries)
+	defer server.Close()
+
+	schema := newRemoteTestSchema(t, server.URL)
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		want      string
+	}{
+		{
+			"aliases",
+			`{ u: user(id: "1") { a: avatar(size: 1) b: avatar(size: 2) who: name } }`,
+			nil,
+			`{"u":{"a":"/avatars/1/1","b":"/avatars/1/2","who":"Alice"}}`,
+		},
+		{
+			"nested aliases",
+			`{ user(id: "1") { friends { small: avatar(size: 1) large: avatar(size: 2) } } }`,
+			nil,
+			`{"user":{"friends":[{"large":"/avatars/2/2","small":"/avatars/2/1"}]}}`,
+		},
+		{
+			"fragments",
+			`{ user(id: "1") { ...userFields friends { ... on User { id } } } }
+			fragment userFields on User { name ...idField }
+			fragment idField on User { id }`,
+			nil,
+			`{"user":{"friends":[{"id":"2"}],"id":"1","name":"Alice"}}`,
+		},
+		{
+			"variables",
+			`query ($id: ID!, $size: Int) { user(id: $id) { avatar(size: $size) } }`,
+			map[string]interface{}{"id": "3", "size": 64},
+			`{"user":{"avatar":"/avatars/3/64"}}`,
+		},
+		{
+			"local directives",
+			`{ user(id: "1") { name @uppercase } }`,
+			nil,
+			`{"user":{"name":"ALICE"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: test.query, VariableValues: test.variables})
+		if len(result.Errors) > 0 {
+			t.Errorf("%s: unexpected errors %v", test.name, result.Errors)
+			continue
+		}
+
+		got, _ := json.Marshal(result.Data)
+		if string(got) != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	for _, query := range queries {
+		if strings.Contains(query, "uppercase") {
+			t.Errorf("local directives should not be forwarded, got %s", query)
+		}
+	}
+}
+
+func TestRemoteSchemaErrors(t *testing.T) {
+	var queries []string
+	server := newRemoteServer(t, &queries)
+	defer server.Close()
+
+	schema := newRemoteTestSchema(t, server.URL)
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ fail user(id: "1") { id } }`})
+	if len(result.Errors) != 1 || result.Errors[0].Message != "remote failure" {
+		t.Errorf("expected the remote error, got %v", result.Errors)
+	}
+	if !reflect.DeepEqual(result.Data, map[string]interface{}{"fail": nil, "user": map[string]interface{}{"id": "1"}}) {
+		t.Errorf("got data %v", result.Data)
+	}
+
+	server.Close()
+	result = graphql.Do(graphql.Params{Schema: schema, RequestString: `{ user(id: "1") { id } }`})
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "unable to reach remote GraphQL endpoint") {
+		t.Errorf("expected the endpoint to be unreachable, got %v", result.Errors)
+	}
+}
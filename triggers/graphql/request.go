@@ -0,0 +1,211 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	mediaTypeJSON            = "application/json"
+	mediaTypeGraphQL         = "application/graphql"
+	mediaTypeForm            = "application/x-www-form-urlencoded"
+	mediaTypeGraphQLResponse = "application/graphql-response+json"
+)
+
+// graphQLRequest holds the parameters of a GraphQL over HTTP request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// requestError is an error raised while reading a request, along with the HTTP status to reply with
+type requestError struct {
+	status int
+	msg    string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+func newRequestError(status int, format string, args ...interface{}) *requestError {
+	return &requestError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+// parsePostRequest reads the GraphQL parameters from the request body, based on its media type
+func parsePostRequest(r *http.Request) (*graphQLRequest, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil, newRequestError(http.StatusUnsupportedMediaType, "Missing content type. Must be one of %s, %s or %s for POST methods.", mediaTypeJSON, mediaTypeGraphQL, mediaTypeForm)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, newRequestError(http.StatusBadRequest, "Invalid content type '%s': %v", contentType, err)
+	}
+
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		return nil, newRequestError(http.StatusUnsupportedMediaType, "Unsupported charset '%s'. Only utf-8 is supported.", charset)
+	}
+
+	switch mediaType {
+	case mediaTypeJSON:
+		req := &graphQLRequest{}
+		err := json.NewDecoder(r.Body).Decode(req)
+		if isBodyTooLarge(err) {
+			return nil, newRequestError(http.StatusRequestEntityTooLarge, "Request body too large.")
+		}
+		if err != nil && err != io.EOF {
+			return nil, newRequestError(http.StatusBadRequest, "Invalid JSON body: %v", err)
+		}
+		return req, nil
+	case mediaTypeGraphQL:
+		body, err := ioutil.ReadAll(r.Body)
+		if isBodyTooLarge(err) {
+			return nil, newRequestError(http.StatusRequestEntityTooLarge, "Request body too large.")
+		}
+		if err != nil {
+			return nil, newRequestError(http.StatusBadRequest, "Unable to read request body: %v", err)
+		}
+		return &graphQLRequest{Query: string(body)}, nil
+	case mediaTypeForm:
+		err := r.ParseForm()
+		if isBodyTooLarge(err) {
+			return nil, newRequestError(http.StatusRequestEntityTooLarge, "Request body too large.")
+		}
+		if err != nil {
+			return nil, newRequestError(http.StatusBadRequest, "Invalid form body: %v", err)
+		}
+		return parseValues(r.PostForm)
+	}
+
+	return nil, newRequestError(http.StatusUnsupportedMediaType, "Invalid content type '%s'. Must be one of %s, %s or %s for POST methods.", mediaType, mediaTypeJSON, mediaTypeGraphQL, mediaTypeForm)
+}
+
+// parseValues reads the GraphQL parameters from url encoded values, where variables are JSON encoded
+func parseValues(values url.Values) (*graphQLRequest, error) {
+	req := &graphQLRequest{
+		Query:         values.Get("query"),
+		OperationName: values.Get("operationName"),
+	}
+
+	if vars := values.Get("variables"); vars != "" {
+		if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+			return nil, newRequestError(http.StatusBadRequest, "Invalid variables: %v", err)
+		}
+	}
+
+	return req, nil
+}
+
+// parseQuery parses the query of a request once, for the checks made before execution and for the execution itself.
+// The errors of a query which does not parse are returned as they are reported to clients.
+func parseQuery(query string) (*ast.Document, []gqlerrors.FormattedError) {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+
+	return doc, nil
+}
+
+// operationType returns the type of the operation of the document which will be executed for the request, i.e.
+// query, mutation or subscription. An empty string is returned if the operation can't be determined, in which case
+// execution will report the error.
+func operationType(doc *ast.Document, operationName string) string {
+	if doc == nil {
+		return ""
+	}
+
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		opDef, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		if operationName == "" {
+			if op != nil {
+				// Ambiguous, operationName is required
+				return ""
+			}
+			op = opDef
+		} else if opDef.Name != nil && opDef.Name.Value == operationName {
+			op = opDef
+		}
+	}
+
+	if op == nil {
+		return ""
+	}
+
+	return op.Operation
+}
+
+// negotiateResponseType picks the media type of the response from the Accept header. application/json is used when
+// the client does not express a preference, an error is returned if no supported type is acceptable.
+func negotiateResponseType(accept string) (string, error) {
+	if strings.TrimSpace(accept) == "" {
+		return mediaTypeJSON, nil
+	}
+
+	var jsonQ, gqlQ, multipartQ float64 = -1, -1, -1
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if val, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(val, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case mediaTypeGraphQLResponse:
+			gqlQ = maxQ(gqlQ, q)
+		case mediaTypeJSON, "application/*", "*/*":
+			jsonQ = maxQ(jsonQ, q)
+		case mediaTypeMultipart:
+			multipartQ = maxQ(multipartQ, q)
+		}
+	}
+
+	if gqlQ > 0 && gqlQ >= jsonQ {
+		return mediaTypeGraphQLResponse, nil
+	}
+
+	if jsonQ > 0 {
+		return mediaTypeJSON, nil
+	}
+
+	// Clients accepting only incremental responses receive a single part when the query is not incremental
+	if multipartQ > 0 {
+		return mediaTypeMultipart, nil
+	}
+
+	return "", errors.New("Not acceptable. The response can be served as " + mediaTypeGraphQLResponse + ", " + mediaTypeJSON + " or " + mediaTypeMultipart + ".")
+}
+
+func maxQ(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,146 @@
+package graphql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateResponseType(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", mediaTypeJSON},
+		{"application/json", mediaTypeJSON},
+		{"application/graphql-response+json", mediaTypeGraphQLResponse},
+		{"application/json, application/graphql-response+json", mediaTypeGraphQLResponse},
+		{"application/json;q=0.9, application/graphql-response+json;q=0.5", mediaTypeJSON},
+		{"application/graphql-response+json;q=0.5, */*;q=0.5", mediaTypeGraphQLResponse},
+		{"application/*", mediaTypeJSON},
+		{"*/*", mediaTypeJSON},
+		{"text/html, */*;q=0.1", mediaTypeJSON},
+		{"application/graphql-response+json;q=0, application/json", mediaTypeJSON},
+		{"multipart/mixed", mediaTypeMultipart},
+		{"multipart/mixed;deferSpec=20220824", mediaTypeMultipart},
+		{"multipart/mixed;deferSpec=20220824, application/json", mediaTypeJSON},
+		{"multipart/mixed;q=0", ""},
+		{"text/html", ""},
+		{"application/json;q=0", ""},
+		{"application/json;q=abc", ""},
+		{"not a media type", ""},
+	}
+
+	for _, test := range tests {
+		got, err := negotiateResponseType(test.accept)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("Accept '%s': expected an error, got %s", test.accept, got)
+			}
+			continue
+		}
+
+		if err != nil || got != test.want {
+			t.Errorf("Accept '%s': got %s, %v, want %s", test.accept, got, err, test.want)
+		}
+	}
+}
+
+func TestParsePostRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		query       string
+		variables   int
+	}{
+		{"json", "application/json", `{"query":"{ user { id } }","variables":{"id":"1"}}`, 0, "{ user { id } }", 1},
+		{"json with charset", "application/json; charset=UTF-8", `{"query":"{ user { id } }"}`, 0, "{ user { id } }", 0},
+		{"empty json", "application/json", ``, 0, "", 0},
+		{"invalid json", "application/json", `{"query":`, http.StatusBadRequest, "", 0},
+		{"graphql", "application/graphql", `{ user { id } }`, 0, "{ user { id } }", 0},
+		{"form", "application/x-www-form-urlencoded", `query=%7B+user+%7B+id+%7D+%7D&variables=%7B%22id%22%3A%221%22%7D`, 0, "{ user { id } }", 1},
+		{"form with invalid variables", "application/x-www-form-urlencoded", `query=x&variables=%7B`, http.StatusBadRequest, "", 0},
+		{"no content type", "", `{}`, http.StatusUnsupportedMediaType, "", 0},
+		{"invalid content type", "application/json; charset", `{}`, http.StatusBadRequest, "", 0},
+		{"charset mismatch", "application/json; charset=ISO-8859-1", `{}`, http.StatusUnsupportedMediaType, "", 0},
+		{"unsupported content type", "text/plain", `{ user { id } }`, http.StatusUnsupportedMediaType, "", 0},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/graphql", strings.NewReader(test.body))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+
+		req, err := parsePostRequest(r)
+		if test.status != 0 {
+			reqErr, ok := err.(*requestError)
+			if !ok || reqErr.status != test.status {
+				t.Errorf("%s: got %v, want status %d", test.name, err, test.status)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if req.Query != test.query || len(req.Variables) != test.variables {
+			t.Errorf("%s: got %+v", test.name, req)
+		}
+	}
+}
+
+func TestParsePostRequestTooLarge(t *testing.T) {
+	bodies := map[string]string{
+		"application/json":                  `{"query":"{ user { id name } }"}`,
+		"application/graphql":               `{ user { id name } }`,
+		"application/x-www-form-urlencoded": `query=%7B+user+%7B+id+name+%7D+%7D`,
+	}
+
+	for contentType, body := range bodies {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.Body = http.MaxBytesReader(w, r.Body, 8)
+
+		_, err := parsePostRequest(r)
+		if reqErr, ok := err.(*requestError); !ok || reqErr.status != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: got %v, want status %d", contentType, err, http.StatusRequestEntityTooLarge)
+		}
+	}
+}
+
+func TestOperationType(t *testing.T) {
+	tests := []struct {
+		query         string
+		operationName string
+		want          string
+	}{
+		{`{ user { id } }`, "", "query"},
+		{`mutation { addUser { id } }`, "", "mutation"},
+		{`query A { user { id } } mutation B { addUser { id } }`, "B", "mutation"},
+		{`query A { user { id } } mutation B { addUser { id } }`, "", ""},
+		{`query A { user { id } }`, "C", ""},
+	}
+
+	for _, test := range tests {
+		doc, errs := parseQuery(test.query)
+		if errs != nil {
+			t.Fatalf("%s: %v", test.query, errs)
+		}
+		if got := operationType(doc, test.operationName); got != test.want {
+			t.Errorf("%s, operation '%s': got '%s', want '%s'", test.query, test.operationName, got, test.want)
+		}
+	}
+
+	if doc, errs := parseQuery(`{ user {`); doc != nil || len(errs) != 1 {
+		t.Errorf("expected a syntax error, got %v", errs)
+	}
+	if got := operationType(nil, ""); got != "" {
+		t.Errorf("got '%s' without a document", got)
+	}
+}
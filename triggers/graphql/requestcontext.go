@@ -0,0 +1,364 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// requestStore holds the request context of a GraphQL request: values which handlers reply with in their
+// requestContext. It is shared by the executions of the request, the initial one and those of deferred fragments.
+type requestStore struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+func newRequestStore() *requestStore {
+	return &requestStore{values: make(map[string]interface{})}
+}
+
+// snapshot returns a copy of the values stored so far
+func (s *requestStore) snapshot() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return copyValues(s.values)
+}
+
+// merge stores the changes, a nil value removes its key
+func (s *requestStore) merge(changes map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	applyChanges(s.values, changes)
+}
+
+// requestContextKey is the context key of the request context of an execution, and then of the field being resolved
+const requestContextKey contextKey = "requestContext"
+
+// execution is the request context of an execution of the operation. Sibling fields resolve in parallel, so the
+// handler of a root field sees the values stored before the execution started, along with the values stored by the
+// handlers of the root fields it depends on, which it waits for.
+type execution struct {
+	store *requestStore
+	base  map[string]interface{}
+
+	// fields holds the root fields of the operation
+	fields *operationFields
+
+	mu      sync.Mutex
+	replies map[string]*fieldReply
+}
+
+// fieldReply is the outcome of a root field: the changes its handler and those of the fields it depends on made to
+// the values the execution started with. done is closed once the field is resolved, whether it failed or not.
+type fieldReply struct {
+	done    chan struct{}
+	changes map[string]interface{}
+}
+
+// withRequestContext starts an execution of the operation of the document, whose handlers share the values of the
+// store
+func withRequestContext(ctx context.Context, store *requestStore, doc *ast.Document, operationName string, variables map[string]interface{}) context.Context {
+	exec := &execution{
+		store:   store,
+		base:    store.snapshot(),
+		fields:  rootFields(doc, operationName, variables),
+		replies: make(map[string]*fieldReply),
+	}
+
+	return context.WithValue(ctx, requestContextKey, exec)
+}
+
+func executionFromContext(ctx context.Context) *execution {
+	if ctx == nil {
+		return nil
+	}
+
+	exec, _ := ctx.Value(requestContextKey).(*execution)
+	return exec
+}
+
+func (e *execution) reply(key string) *fieldReply {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	reply, ok := e.replies[key]
+	if !ok {
+		reply = &fieldReply{done: make(chan struct{})}
+		e.replies[key] = reply
+	}
+	return reply
+}
+
+// await waits for the root fields named by dependsOn to be resolved, and returns the changes their handlers made.
+// false is returned when cancel is closed first. The root fields of a mutation are resolved one after another, so the
+// field with the response key only depends on the fields before it.
+func (e *execution) await(key string, dependsOn []string, cancel <-chan struct{}) (map[string]interface{}, bool) {
+	changes := make(map[string]interface{})
+
+	for _, field := range dependsOn {
+		for _, dep := range e.fields.byName[field] {
+			if e.fields.mutation && e.fields.position[dep] > e.fields.position[key] {
+				continue
+			}
+
+			reply := e.reply(dep)
+			select {
+			case <-reply.done:
+			case <-cancel:
+				return nil, false
+			}
+
+			for k, v := range reply.changes {
+				changes[k] = v
+			}
+		}
+	}
+
+	return changes, true
+}
+
+// resolved marks the root field with the response key as resolved, the fields depending on it stop waiting
+func (e *execution) resolved(key string) {
+	close(e.reply(key).done)
+}
+
+// requestView is the request context visible to the handler of a root field
+type requestView struct {
+	exec *execution
+	key  string
+
+	// changes holds the changes made by the handlers of the fields it depends on
+	changes map[string]interface{}
+}
+
+// withRequestView sets the request context visible to the handler of the root field with the response key
+func withRequestView(ctx context.Context, exec *execution, key string, changes map[string]interface{}) context.Context {
+	return context.WithValue(ctx, requestContextKey, &requestView{exec: exec, key: key, changes: changes})
+}
+
+func requestViewFromContext(ctx context.Context) *requestView {
+	if ctx == nil {
+		return nil
+	}
+
+	view, _ := ctx.Value(requestContextKey).(*requestView)
+	return view
+}
+
+// visible returns a copy of the values visible to the handler
+func (v *requestView) visible() map[string]interface{} {
+	values := copyValues(v.exec.base)
+	applyChanges(values, v.changes)
+	return values
+}
+
+// store stores the requestContext of the reply of the handler, for the fields depending on it and the deferred
+// fragments of the request
+func (v *requestView) store(results map[string]*data.Attribute) error {
+	val := replyValue(results, "requestContext")
+	if val == nil {
+		return nil
+	}
+
+	values, err := data.CoerceToObject(val)
+	if err != nil {
+		return err
+	}
+
+	changes := copyValues(v.changes)
+	for k, value := range values {
+		changes[k] = value
+	}
+	v.exec.reply(v.key).changes = changes
+	v.exec.store.merge(values)
+
+	return nil
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
+
+// applyChanges sets the changed values, a nil value removes its key
+func applyChanges(values, changes map[string]interface{}) {
+	for k, v := range changes {
+		if v == nil {
+			delete(values, k)
+		} else {
+			values[k] = v
+		}
+	}
+}
+
+// parseDependsOn parses the dependsOn setting of a handler, the root fields it depends on as a comma separated list
+// or an array
+func parseDependsOn(val interface{}) ([]string, error) {
+	var fields []string
+
+	switch val := val.(type) {
+	case nil:
+	case string:
+		for _, field := range strings.Split(val, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	case []interface{}:
+		for _, field := range val {
+			name, ok := field.(string)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("invalid dependsOn '%v'", val)
+			}
+			fields = append(fields, name)
+		}
+	default:
+		return nil, fmt.Errorf("invalid dependsOn '%v'", val)
+	}
+
+	return fields, nil
+}
+
+// checkDependencies returns an error when the dependencies of the fields form a cycle, the handlers of the fields
+// would wait for each other
+func checkDependencies(dependencies map[string][]string) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+
+	var visit func(field string) error
+	visit = func(field string) error {
+		switch state[field] {
+		case visiting:
+			return fmt.Errorf("the dependencies of field '%s' form a cycle", field)
+		case visited:
+			return nil
+		}
+
+		state[field] = visiting
+		for _, dep := range dependencies[field] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[field] = visited
+		return nil
+	}
+
+	for field := range dependencies {
+		if err := visit(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// operationFields holds the root fields of an operation which are resolved
+type operationFields struct {
+	// byName holds the response keys of the fields by field name, in document order
+	byName map[string][]string
+
+	// position holds the position of each response key in the document
+	position map[string]int
+
+	mutation bool
+}
+
+// rootFields returns the root fields of the operation. Fields excluded by @skip or @include are left out, as they are
+// not resolved.
+func rootFields(doc *ast.Document, operationName string, variables map[string]interface{}) *operationFields {
+	fields := &operationFields{byName: make(map[string][]string), position: make(map[string]int)}
+	if doc == nil {
+		return fields
+	}
+
+	var op *ast.OperationDefinition
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				op = def
+			}
+		}
+	}
+	if op == nil {
+		return fields
+	}
+	fields.mutation = op.Operation == ast.OperationTypeMutation
+
+	// Variables which are not supplied take their default value
+	values := copyValues(variables)
+	for _, def := range op.VariableDefinitions {
+		if _, ok := values[def.Variable.Name.Value]; !ok && def.DefaultValue != nil {
+			values[def.Variable.Name.Value] = valueFromAST(def.DefaultValue)
+		}
+	}
+
+	included := func(directives []*ast.Directive) bool {
+		for _, directive := range directives {
+			if directive.Name.Value != "skip" && directive.Name.Value != "include" {
+				continue
+			}
+			for _, arg := range directive.Arguments {
+				if arg.Name.Value != "if" {
+					continue
+				}
+				cond, _ := valueFromAST(arg.Value).(bool)
+				if variable, ok := arg.Value.(*ast.Variable); ok {
+					cond, _ = values[variable.Name.Value].(bool)
+				}
+				if cond == (directive.Name.Value == "skip") {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	visited := make(map[string]bool)
+
+	var collect func(set *ast.SelectionSet)
+	collect = func(set *ast.SelectionSet) {
+		if set == nil {
+			return
+		}
+
+		for _, selection := range set.Selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				key := responseKey(selection)
+				if _, seen := fields.position[key]; included(selection.Directives) && !seen {
+					fields.position[key] = len(fields.position)
+					fields.byName[selection.Name.Value] = append(fields.byName[selection.Name.Value], key)
+				}
+			case *ast.InlineFragment:
+				if included(selection.Directives) {
+					collect(selection.SelectionSet)
+				}
+			case *ast.FragmentSpread:
+				name := selection.Name.Value
+				if def, ok := fragments[name]; ok && !visited[name] && included(selection.Directives) {
+					visited[name] = true
+					collect(def.SelectionSet)
+				}
+			}
+		}
+	}
+	collect(op.SelectionSet)
+
+	return fields
+}
@@ -0,0 +1,215 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestRequestContext(t *testing.T) {
+	var mu sync.Mutex
+	var contexts map[string]string
+
+	// The handler records the keys of the request context it sees, by id, and adds its own key
+	trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, nil),
+		newTestHandler("user", func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+			args, _ := triggerData["args"].(map[string]interface{})
+
+			var keys []string
+			for k := range triggerData["requestContext"].(map[string]interface{}) {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			mu.Lock()
+			contexts[fmt.Sprint(args["id"])] = strings.Join(keys, ",")
+			mu.Unlock()
+
+			return map[string]interface{}{
+				"data":           map[string]interface{}{"id": args["id"]},
+				"requestContext": map[string]interface{}{"viewer" + fmt.Sprint(args["id"]): true},
+			}, nil
+		}))
+
+	tests := []struct {
+		query    string
+		contexts map[string]string
+	}{
+		{`{ user(id: "1") { id } ... @defer { other: user(id: "2") { id } } }`, map[string]string{"1": "", "2": "viewer1"}},
+		{`{ user(id: "1") { id } other: user(id: "2") { id } }`, map[string]string{"1": "", "2": ""}},
+		{`{ user(id: "1") { id } ... @defer { a: user(id: "2") { id } } ... @defer { b: user(id: "3") { id } } }`, map[string]string{"1": "", "2": "viewer1", "3": "viewer1"}},
+	}
+
+	for _, test := range tests {
+		contexts = make(map[string]string)
+
+		w := postQuery(trg, "multipart/mixed;deferSpec=20220824", test.query)
+		if w.Code != 200 {
+			t.Fatalf("unexpected response %d: %s", w.Code, w.Body)
+		}
+
+		if !reflect.DeepEqual(contexts, test.contexts) {
+			t.Errorf("%s: got request contexts %v, want %v", test.query, contexts, test.contexts)
+		}
+	}
+}
+
+const (
+	dependencyTypes  = `[{"Name":"user","Fields":{"name":{"Type":"graphql.String"}}},{"Name":"address","Fields":{"city":{"Type":"graphql.String"}}}]`
+	dependencySchema = `{"Query":{"Name":"Query","Fields":{"user":{"Type":"user","Args":{"id":{"Type":"graphql.String"}}},"address":{"Type":"address"}}}}`
+)
+
+func TestRequestContextDependencies(t *testing.T) {
+	// The user handler stores a key per id, the address handler depends on it and replies with the keys it sees
+	user := newTestHandler("user", func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+		args, _ := triggerData["args"].(map[string]interface{})
+		if args["id"] == "fail" {
+			return nil, errors.New("no such user")
+		}
+		time.Sleep(10 * time.Millisecond)
+		return map[string]interface{}{
+			"data":           map[string]interface{}{"name": "Matt"},
+			"requestContext": map[string]interface{}{"viewer" + fmt.Sprint(args["id"]): true},
+		}, nil
+	})
+	address := newTestHandlerWith(map[string]interface{}{"resolverFor": "address", "dependsOn": "user"},
+		func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+			var keys []string
+			for k := range triggerData["requestContext"].(map[string]interface{}) {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return map[string]interface{}{"data": map[string]interface{}{"city": strings.Join(keys, ",")}}, nil
+		})
+
+	trg := newTestTrigger(t, testSettings(t, dependencyTypes, dependencySchema, map[string]interface{}{"resolverTimeout": "1s"}), user, address)
+
+	tests := []struct {
+		query string
+		city  string
+	}{
+		{`{ user(id: "1") { name } address { city } }`, "viewer1"},
+		{`{ address { city } user(id: "1") { name } }`, "viewer1"},
+		{`{ a: user(id: "1") { name } b: user(id: "2") { name } address { city } }`, "viewer1,viewer2"},
+		{`{ ...on Query { user(id: "1") { name } } address { city } }`, "viewer1"},
+		{`{ user(id: "fail") { name } address { city } }`, ""},
+		// Fields which are not resolved are not waited for
+		{`{ address { city } }`, ""},
+		{`{ user(id: "1") @skip(if: true) { name } address { city } }`, ""},
+		{`query($with: Boolean = false) { user(id: "1") @include(if: $with) { name } address { city } }`, ""},
+	}
+
+	for _, test := range tests {
+		w := postQuery(trg, mediaTypeJSON, test.query)
+		result := decodeResult(t, w)
+
+		address, _ := result["data"].(map[string]interface{})["address"].(map[string]interface{})
+		if address == nil || address["city"] != test.city {
+			t.Errorf("%s: got %s, want city '%s'", test.query, w.Body, test.city)
+		}
+	}
+}
+
+func TestDependencyCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		userDeps interface{}
+		addrDeps interface{}
+		valid    bool
+	}{
+		{"one way", nil, "user", true},
+		{"list", nil, []interface{}{"user"}, true},
+		{"cycle", "address", "user", false},
+		{"self", "user", nil, false},
+		{"invalid", nil, 42, false},
+	}
+
+	for _, test := range tests {
+		handlers := []*trigger.Handler{
+			newTestHandlerWith(map[string]interface{}{"resolverFor": "user", "dependsOn": test.userDeps}, replyData(nil)),
+			newTestHandlerWith(map[string]interface{}{"resolverFor": "address", "dependsOn": test.addrDeps}, replyData(nil)),
+		}
+
+		trg := NewFactory(nil).New(&trigger.Config{Id: "test", Settings: testSettings(t, dependencyTypes, dependencySchema, nil)}).(*GraphQLTrigger)
+		if err := trg.Initialize(&testInitContext{handlers: handlers}); (err == nil) != test.valid {
+			t.Errorf("%s: got %v, valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestMutationDependencies(t *testing.T) {
+	user, address := &trigger.Handler{}, &trigger.Handler{}
+	trg := &GraphQLTrigger{handlerDependencies: map[*trigger.Handler][]string{address: {"user"}}}
+
+	// The user field stores a viewer, the address field resolves to the keys it sees
+	field := func(handler *trigger.Handler, resolve graphql.FieldResolveFn) *graphql.Field {
+		return &graphql.Field{Type: graphql.String, Resolve: trg.concurrentResolver(handler, resolve)}
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}}}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"user": field(user, func(p graphql.ResolveParams) (interface{}, error) {
+					attr, _ := data.NewAttribute("requestContext", data.TypeAny, map[string]interface{}{"viewer": true})
+					return "Matt", requestViewFromContext(p.Context).store(map[string]*data.Attribute{"requestContext": attr})
+				}),
+				"address": field(address, func(p graphql.ResolveParams) (interface{}, error) {
+					var keys []string
+					for k := range requestViewFromContext(p.Context).visible() {
+						keys = append(keys, k)
+					}
+					return strings.Join(keys, ","), nil
+				}),
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		city  string
+	}{
+		{`mutation { user address }`, "viewer"},
+		// The fields of a mutation are resolved one after another, so a field does not wait for a later one
+		{`mutation { address user }`, ""},
+	}
+
+	for _, test := range tests {
+		doc, err := parser.Parse(parser.ParseParams{Source: test.query})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		done := make(chan *graphql.Result, 1)
+		go func() {
+			done <- graphql.Execute(graphql.ExecuteParams{
+				Schema:  schema,
+				AST:     doc,
+				Context: withRequestContext(context.Background(), newRequestStore(), doc, "", nil),
+			})
+		}()
+
+		select {
+		case result := <-done:
+			if len(result.Errors) > 0 || result.Data.(map[string]interface{})["address"] != test.city {
+				t.Errorf("%s: got %v, want address '%s'", test.query, result, test.city)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: the mutation did not complete", test.query)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+const defaultSchemaWatchInterval = 5 * time.Second
+
+// schemaDef is the content of a schema file, it holds the same types & schema as the trigger settings
+type schemaDef struct {
+	Types  []interface{}          `json:"types"`
+	Schema map[string]interface{} `json:"schema"`
+}
+
+func readSchemaFile(path string) (*schemaDef, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read schema file '%s': %v", path, err)
+	}
+
+	def := &schemaDef{}
+	if err := json.Unmarshal(content, def); err != nil {
+		return nil, fmt.Errorf("unable to parse schema file '%s': %v", path, err)
+	}
+
+	return def, nil
+}
+
+// watchSchemaFile starts polling the schema file of a tenant for changes, and rebuilds its schema when it is modified.
+// Requests which are in flight keep executing against the schema they started with, if the new schema fails to build
+// the current one is kept. The file is compared with its state when watchSchemaFile returns.
+func (t *GraphQLTrigger) watchSchemaFile(tenant, path string, interval time.Duration, done chan struct{}) {
+	var lastMod time.Time
+	var lastSize int64
+
+	if info, err := os.Stat(path); err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+
+	go t.pollSchemaFile(tenant, path, interval, lastMod, lastSize, done)
+}
+
+func (t *GraphQLTrigger) pollSchemaFile(tenant, path string, interval time.Duration, lastMod time.Time, lastSize int64, done chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				log.Warnf("Unable to check schema file '%s' for changes: %v", path, err)
+				continue
+			}
+
+			if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+				continue
+			}
+			lastMod, lastSize = info.ModTime(), info.Size()
+
+			t.reloadSchema(tenant)
+		}
+	}
+}
+
+// reloadSchema rebuilds the schema of a tenant and swaps it in, the current schema is kept if the build fails
+func (t *GraphQLTrigger) reloadSchema(tenant string) {
+	name := "the GraphQL schema"
+	if tenant != "" {
+		name = fmt.Sprintf("the GraphQL schema of tenant '%s'", tenant)
+	}
+
+	schema, err := t.buildSchema(tenant)
+	if err != nil {
+		log.Errorf("Unable to reload %s for trigger '%s', keeping the current schema: %v", name, t.config.Id, err)
+		return
+	}
+
+	t.setSchema(tenant, schema)
+	log.Infof("Reloaded %s for trigger '%s'", name, t.config.Id)
+}
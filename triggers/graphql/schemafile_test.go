@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSchemaFile writes a schema file serving the user type with the fields
+func writeSchemaFile(t *testing.T, path, fields string) {
+	content := `{"types":[{"Name":"user","Fields":` + fields + `}],"schema":` + testSchema + `}`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaFileReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schemafile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "schema.json")
+	writeSchemaFile(t, path, `{"name":{"Type":"graphql.String"}}`)
+
+	trg := newTestTrigger(t, map[string]interface{}{"port": "0", "path": "/graphql", "operation": "QUERY", "schemaFile": path},
+		newTestHandler("user", replyData(map[string]interface{}{"name": "Matt", "email": "matt@example.org"})))
+
+	const interval = 10 * time.Millisecond
+	done := make(chan struct{})
+	defer close(done)
+	trg.watchSchemaFile("", path, interval, done)
+
+	const query = `{ user(id: "1") { email } }`
+	if result := decodeResult(t, postQuery(trg, "", query)); result["errors"] == nil {
+		t.Fatalf("expected an error for an unknown field, got %v", result)
+	}
+
+	writeSchemaFile(t, path, `{"name":{"Type":"graphql.String"},"email":{"Type":"graphql.String"}}`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		result := decodeResult(t, postQuery(trg, "", query))
+		if result["errors"] == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the schema was not reloaded: %v", result)
+		}
+		time.Sleep(interval)
+	}
+
+	// A broken file keeps the current schema
+	if err := ioutil.WriteFile(path, []byte(`{"types": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * interval)
+
+	result := decodeResult(t, postQuery(trg, "", query))
+	if user, _ := result["data"].(map[string]interface{})["user"].(map[string]interface{}); result["errors"] != nil || user["email"] != "matt@example.org" {
+		t.Errorf("the previous schema is not served: %v", result)
+	}
+}
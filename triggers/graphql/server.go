@@ -1,7 +1,9 @@
 package graphql
 
 import (
+	"context"
 	"crypto/md5"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -10,6 +12,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Graceful shutdown HttpServer from: https://github.com/corneldamian/httpway/blob/master/server.go
@@ -32,6 +37,17 @@ type Server struct {
 	lastError        error
 	serverGroup      *sync.WaitGroup
 	clientsGroup     chan bool
+	accessLog        *accessLog
+
+	// h2c enables HTTP/2 without TLS, both with prior knowledge and by upgrading HTTP/1.1 requests
+	h2c bool
+
+	// certFile & keyFile enable TLS, over which HTTP/2 is negotiated
+	certFile string
+	keyFile  string
+
+	// socketPath is the Unix domain socket to listen on instead of Addr
+	socketPath string
 }
 
 // InstanceID the server instance id
@@ -55,11 +71,24 @@ func (s *Server) Start() error {
 		addr = ":http"
 	}
 
-	listener, err := net.Listen("tcp", addr)
+	var listener net.Listener
+	var err error
+	if s.socketPath != "" {
+		addr = s.socketPath
+		listener, err = listenUnix(s.socketPath)
+	} else {
+		listener, err = net.Listen("tcp", addr)
+	}
 	if err != nil {
 		return err
 	}
 
+	if s.certFile != "" {
+		if listener, err = s.tlsListener(listener); err != nil {
+			return err
+		}
+	}
+
 	hostname, _ := os.Hostname()
 	s.serverInstanceID = fmt.Sprintf("%x", md5.Sum([]byte(hostname+addr)))
 
@@ -73,7 +102,12 @@ func (s *Server) Start() error {
 	//    }
 	//}
 	//
-	s.Handler = &serverHandler{s.Handler, s.clientsGroup, s.serverInstanceID}
+	s.Handler = &serverHandler{s.Handler, s.clientsGroup, s.serverInstanceID, s.accessLog}
+
+	// The HTTP/2 streams of a h2c connection are each served by the server handler, so it must wrap the latter
+	if s.h2c {
+		s.Handler = h2c.NewHandler(s.Handler, &http2.Server{IdleTimeout: s.IdleTimeout})
+	}
 
 	s.serverGroup.Add(1)
 	go func() {
@@ -92,6 +126,46 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// listenUnix listens on a Unix domain socket, replacing the socket left behind by a previous run unless it is still
+// in use. The socket file is removed when the listener is closed.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("unable to listen on '%s': the file exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("unable to listen on '%s': the socket is in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.Listen("unix", path)
+}
+
+// tlsListener wraps the listener with TLS, offering HTTP/2 & HTTP/1.1
+func (s *Server) tlsListener(listener net.Listener) (net.Listener, error) {
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("unable to load the TLS certificate: %v", err)
+	}
+
+	if s.TLSConfig == nil {
+		s.TLSConfig = &tls.Config{}
+	}
+	s.TLSConfig.Certificates = []tls.Certificate{cert}
+
+	if err := http2.ConfigureServer(s.Server, &http2.Server{IdleTimeout: s.IdleTimeout}); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return tls.NewListener(listener, s.TLSConfig), nil
+}
+
 // Stop sends stop command to the server
 func (s *Server) Stop() error {
 	if s.listener == nil {
@@ -148,6 +222,7 @@ type serverHandler struct {
 	handler          http.Handler
 	clientsGroup     chan bool
 	serverInstanceID string
+	accessLog        *accessLog
 }
 
 func (sh *serverHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -158,5 +233,32 @@ func (sh *serverHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Add("X-Server-Instance-Id", sh.serverInstanceID)
 
-	sh.handler.ServeHTTP(w, r)
+	if sh.accessLog == nil {
+		sh.handler.ServeHTTP(w, r)
+		return
+	}
+
+	entry := &accessEntry{
+		Time:           time.Now(),
+		RemoteAddr:     r.RemoteAddr,
+		Method:         r.Method,
+		Path:           r.URL.Path,
+		Proto:          r.Proto,
+		ClaimedSubject: claimedSubject(r),
+		Referer:        r.Referer(),
+		UserAgent:      r.UserAgent(),
+		uri:            r.RequestURI,
+	}
+	recorder := &accessRecorder{ResponseWriter: w}
+
+	sh.handler.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), accessKey, entry)))
+
+	entry.Status = recorder.status
+	if entry.Status == 0 {
+		entry.Status = http.StatusOK
+	}
+	entry.Bytes = recorder.bytes
+	entry.DurationMs = float64(time.Since(entry.Time)) / float64(time.Millisecond)
+
+	sh.accessLog.log(entry)
 }
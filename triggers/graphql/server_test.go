@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"golang.org/x/net/http2"
+)
+
+// startTestTrigger starts a trigger with the settings & handlers, and returns the address it listens on. The trigger
+// must be stopped when done.
+func startTestTrigger(t *testing.T, settings map[string]interface{}, handlers ...*trigger.Handler) (*GraphQLTrigger, string) {
+	trg := newTestTrigger(t, settings, handlers...)
+	if err := trg.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	addr := trg.server.listener.Addr().String()
+	if _, port, err := net.SplitHostPort(addr); err == nil {
+		addr = net.JoinHostPort("127.0.0.1", port)
+	}
+	return trg, addr
+}
+
+// queryUser posts the user query with the client, and checks the response over the protocol
+func queryUser(t *testing.T, client *http.Client, url string, protoMajor int) {
+	resp, err := client.Post(url, mediaTypeJSON, strings.NewReader(`{"query":"{ user(id: \"1\") { name } }"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != protoMajor {
+		t.Errorf("got protocol %s, want HTTP/%d", resp.Proto, protoMajor)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"user": map[string]interface{}{"name": "Matt"}}
+	if !reflect.DeepEqual(result["data"], want) {
+		t.Errorf("got %v, want %v", result, want)
+	}
+}
+
+func TestH2C(t *testing.T) {
+	trg, addr := startTestTrigger(t, testSettings(t, testTypes, testSchema, map[string]interface{}{"h2c": true}),
+		newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})))
+	defer trg.Stop()
+
+	// The client speaks HTTP/2 over a plain connection, with prior knowledge
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	queryUser(t, client, "http://"+addr+"/graphql", 2)
+
+	// HTTP/1.1 clients are still served
+	queryUser(t, &http.Client{}, "http://"+addr+"/graphql", 1)
+}
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 and its key to the directory
+func writeTestCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeTestCert(t, dir)
+	trg, addr := startTestTrigger(t, testSettings(t, testTypes, testSchema, map[string]interface{}{"certFile": certFile, "keyFile": keyFile}),
+		newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})))
+	defer trg.Stop()
+
+	tests := []struct {
+		name       string
+		http2      bool
+		protoMajor int
+	}{
+		// HTTP/2 is negotiated with clients supporting it
+		{"http2", true, 2},
+		{"http1", false, 1},
+	}
+
+	for _, test := range tests {
+		transport := &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: test.http2,
+		}
+		if !test.http2 {
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+		queryUser(t, &http.Client{Transport: transport}, "https://"+addr+"/graphql", test.protoMajor)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "socket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "graphql.sock")
+	settings := testSettings(t, testTypes, testSchema, map[string]interface{}{"socketPath": socketPath})
+	delete(settings, "port")
+
+	trg, _ := startTestTrigger(t, settings, newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})))
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	queryUser(t, client, "http://graphql/graphql", 1)
+
+	if err := trg.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("expected the socket file to be removed, got %v", err)
+	}
+}
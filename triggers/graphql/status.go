@@ -0,0 +1,148 @@
+package graphql
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// The statusPolicy setting decides whether failing root fields set the HTTP status of the response
+const (
+	// statusPolicyIgnore leaves the HTTP status unchanged
+	statusPolicyIgnore = "ignore"
+
+	// statusPolicyAny sets the HTTP status when any root field fails
+	statusPolicyAny = "any"
+
+	// statusPolicyAll sets the HTTP status only when no root field resolved a value
+	statusPolicyAll = "all"
+)
+
+// statusError is the error of a handler which replied with a 4xx/5xx status or an error
+type statusError struct {
+	status  int
+	message string
+
+	// safe errors are passed to clients when the trigger masks errors
+	safe bool
+
+	// correlationID identifies the logged error a masked error replaces
+	correlationID string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// Extensions holds the code matching the status, e.g. NOT_FOUND for 404
+func (e *statusError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"code":   statusCode(e.status),
+		"status": e.status,
+	}
+	if e.correlationID != "" {
+		extensions["correlationId"] = e.correlationID
+	}
+
+	return extensions
+}
+
+// statusCode converts an HTTP status to an error code, e.g. 404 to NOT_FOUND
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return fmt.Sprintf("HTTP_%d", status)
+	}
+
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
+
+// replyError returns the error of the handler reply, when it holds a 4xx/5xx status or an error. An error without a
+// status is an internal server error, and the safe reply flags an error which is not masked.
+func replyError(results map[string]*data.Attribute) error {
+	status := 0
+	if val := replyValue(results, "status"); val != nil {
+		status, _ = data.CoerceToInteger(val)
+	}
+
+	message, _ := data.CoerceToString(replyValue(results, "error"))
+
+	if status < http.StatusBadRequest {
+		if message == "" {
+			return nil
+		}
+		status = http.StatusInternalServerError
+	}
+
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	safe, _ := data.CoerceToBoolean(replyValue(results, "safe"))
+
+	return &statusError{status: status, message: message, safe: safe}
+}
+
+// responseStatus applies the status policy to the result, it returns the status of the failing root fields, or 0 when
+// the HTTP status is left unchanged. The highest status is returned when several root fields failed.
+func responseStatus(policy string, result *graphql.Result) int {
+	if policy != statusPolicyAny && policy != statusPolicyAll {
+		return 0
+	}
+
+	if policy == statusPolicyAll {
+		if fields, ok := result.Data.(map[string]interface{}); ok {
+			for _, v := range fields {
+				if v != nil {
+					return 0
+				}
+			}
+		}
+	}
+
+	status := 0
+	for _, fErr := range result.Errors {
+		if len(fErr.Path) != 1 {
+			continue
+		}
+
+		if sErr, ok := originalError(fErr).(*statusError); ok && sErr.status > status {
+			status = sErr.status
+		}
+	}
+
+	return status
+}
+
+// originalError returns the error returned by the resolver of a field. The errors of the thunks returned by
+// concurrent resolvers are wrapped twice by graphql-go.
+func originalError(fErr gqlerrors.FormattedError) error {
+	var err error = fErr
+	for {
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			return err
+		}
+	}
+}
+
+// restoreExtensions sets the extensions of the errors of the result which graphql-go dropped, as it does for the
+// errors of thunks
+func restoreExtensions(result *graphql.Result) {
+	for i, fErr := range result.Errors {
+		if fErr.Extensions != nil {
+			continue
+		}
+		if extended, ok := originalError(fErr).(gqlerrors.ExtendedError); ok {
+			result.Errors[i].Extensions = extended.Extensions()
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHandlerStatus(t *testing.T) {
+	tests := []struct {
+		policy string
+		accept string
+		query  string
+		status int
+		errors int
+		calls  int32
+	}{
+		// Failing fields leave the status unchanged by default, whatever the media type
+		{statusPolicyIgnore, mediaTypeJSON, `{ user(id: "2") { name } other: user(id: "3") { name } }`, http.StatusOK, 2, 2},
+		{statusPolicyIgnore, "", `{ user(id: "2") { name } other: user(id: "3") { name } }`, http.StatusOK, 2, 2},
+		{statusPolicyIgnore, mediaTypeGraphQLResponse, `{ user(id: "2") { name } }`, http.StatusOK, 1, 1},
+		{statusPolicyAny, mediaTypeGraphQLResponse, `{ user(id: "1") { name } other: user(id: "2") { name } }`, http.StatusNotFound, 1, 2},
+		{statusPolicyAll, mediaTypeGraphQLResponse, `{ user(id: "1") { name } other: user(id: "2") { name } }`, http.StatusOK, 1, 2},
+		{statusPolicyAll, mediaTypeGraphQLResponse, `{ user(id: "2") { name } other: user(id: "3") { name } }`, http.StatusNotFound, 2, 2},
+
+		// Invalid queries don't call the handlers
+		{statusPolicyIgnore, mediaTypeJSON, `{ unknown }`, http.StatusOK, 1, 0},
+		{statusPolicyAny, mediaTypeGraphQLResponse, `{ unknown }`, http.StatusBadRequest, 1, 0},
+	}
+
+	for _, test := range tests {
+		var calls int32
+		trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, map[string]interface{}{"statusPolicy": test.policy}),
+			newTestHandler("user", func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				if args, _ := triggerData["args"].(map[string]interface{}); args["id"] == "1" {
+					return map[string]interface{}{"data": map[string]interface{}{"name": "Matt"}}, nil
+				}
+				return map[string]interface{}{"status": 404, "error": "no such user"}, nil
+			}))
+
+		w := postQuery(trg, test.accept, test.query)
+		if w.Code != test.status {
+			t.Errorf("%s, Accept '%s', query %s: got status %d, want %d", test.policy, test.accept, test.query, w.Code, test.status)
+		}
+
+		result := decodeResult(t, w)
+		errs, _ := result["errors"].([]interface{})
+		if len(errs) != test.errors {
+			t.Errorf("%s, Accept '%s', query %s: got %d errors, want %d: %s", test.policy, test.accept, test.query, len(errs), test.errors, w.Body)
+		}
+		if calls != test.calls {
+			t.Errorf("%s, Accept '%s', query %s: handler called %d times, want %d", test.policy, test.accept, test.query, calls, test.calls)
+		}
+
+		// The errors of the handler carry the code of their status
+		if test.calls > 0 {
+			for _, err := range errs {
+				extensions, _ := err.(map[string]interface{})["extensions"].(map[string]interface{})
+				if extensions["code"] != "NOT_FOUND" {
+					t.Errorf("%s, Accept '%s', query %s: unexpected error %v", test.policy, test.accept, test.query, err)
+				}
+			}
+		}
+	}
+}
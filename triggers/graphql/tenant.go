@@ -0,0 +1,119 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+	"github.com/julienschmidt/httprouter"
+)
+
+// The tenantSelector setting decides how the tenant of a request is selected
+const (
+	// tenantByHeader selects the tenant named by the tenantHeader header
+	tenantByHeader = "header"
+
+	// tenantByHost selects the tenant named by the first label of the host, e.g. acme for acme.example.com
+	tenantByHost = "host"
+
+	// tenantByPath selects the tenant named by the path prefix, e.g. acme for /acme/graphql
+	tenantByPath = "path"
+
+	defaultTenantHeader = "X-Tenant-ID"
+
+	// tenantParam is the route parameter holding the tenant, when it is selected by path
+	tenantParam = "tenant"
+)
+
+// tenantSource returns the types & schema of a tenant, or the path of its schema file. The default schema of the
+// trigger settings is returned for the empty tenant.
+func (t *GraphQLTrigger) tenantSource(tenant string) ([]interface{}, map[string]interface{}, string, error) {
+	settings := t.config.Settings
+
+	if tenant != "" {
+		tenants, _ := t.config.Settings["tenants"].(map[string]interface{})
+		if settings, _ = tenants[tenant].(map[string]interface{}); settings == nil {
+			return nil, nil, "", fmt.Errorf("invalid definition for tenant '%s'", tenant)
+		}
+	}
+
+	gqlTypes, _ := settings["types"].([]interface{})
+	fSchema, _ := settings["schema"].(map[string]interface{})
+	path, _ := settings["schemaFile"].(string)
+
+	return gqlTypes, fSchema, path, nil
+}
+
+// tenantIDs returns the tenants of the trigger, the empty tenant stands for the default schema and is only included
+// when it is configured
+func (t *GraphQLTrigger) tenantIDs() []string {
+	tenants, _ := t.config.Settings["tenants"].(map[string]interface{})
+
+	var ids []string
+	if len(tenants) == 0 || t.config.Settings["types"] != nil || t.config.GetSetting("schemaFile") != "" {
+		ids = append(ids, "")
+	}
+	for id := range tenants {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// tenantHandlers returns the handlers resolving the fields of a tenant. Handlers without a tenant setting resolve the
+// fields of every tenant, they come first so that handlers specific to the tenant take precedence.
+func (t *GraphQLTrigger) tenantHandlers(tenant string) []*trigger.Handler {
+	var shared, specific []*trigger.Handler
+
+	for _, handler := range t.handlers {
+		switch handler.GetStringSetting("tenant") {
+		case "":
+			shared = append(shared, handler)
+		case tenant:
+			specific = append(specific, handler)
+		}
+	}
+
+	return append(shared, specific...)
+}
+
+// tenantFor returns the tenant selected by the request, the default schema is served when no tenants are configured
+func (t *GraphQLTrigger) tenantFor(r *http.Request, ps httprouter.Params) string {
+	if tenants, _ := t.config.Settings["tenants"].(map[string]interface{}); len(tenants) == 0 {
+		return ""
+	}
+
+	switch t.config.GetSetting("tenantSelector") {
+	case tenantByHost:
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		return strings.SplitN(host, ".", 2)[0]
+	case tenantByPath:
+		return ps.ByName(tenantParam)
+	}
+
+	header := t.config.GetSetting("tenantHeader")
+	if header == "" {
+		header = defaultTenantHeader
+	}
+
+	return r.Header.Get(header)
+}
+
+// tenantKey is the context key of the tenant of a request
+const tenantKey contextKey = "tenant"
+
+// tenantFromContext returns the tenant of the request being resolved
+func tenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
@@ -0,0 +1,36 @@
+package graphql
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTenantHeader(t *testing.T) {
+	tenants := map[string]interface{}{"acme": testSettings(t, testTypes, testSchema, nil)}
+
+	tests := []struct {
+		name    string
+		tenants map[string]interface{}
+		tenant  string
+		status  int
+	}{
+		// The header is ignored without tenants
+		{"no tenants", nil, "acme", http.StatusOK},
+		{"known tenant", tenants, "acme", http.StatusOK},
+		{"unknown tenant", tenants, "other", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		extra := map[string]interface{}{}
+		if test.tenants != nil {
+			extra["tenants"] = test.tenants
+		}
+		trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, extra),
+			newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})))
+
+		w := postQueryWith(trg, http.Header{"X-Tenant-Id": {test.tenant}}, `{ user(id: "1") { name } }`)
+		if w.Code != test.status {
+			t.Errorf("%s: got status %d, want %d: %s", test.name, w.Code, test.status, w.Body)
+		}
+	}
+}
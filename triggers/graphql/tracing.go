@@ -0,0 +1,201 @@
+package graphql
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Span is the timing of a single resolver execution
+type Span struct {
+	TraceID    string        `json:"traceId"`
+	Path       []interface{} `json:"path"`
+	ParentType string        `json:"parentType"`
+	FieldName  string        `json:"fieldName"`
+	ReturnType string        `json:"returnType"`
+	HandlerID  string        `json:"handlerId"`
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// SpanExporter exports the resolver spans of a GraphQL request
+type SpanExporter interface {
+	Export(spans []*Span) error
+}
+
+// SpanExporterFactory creates a SpanExporter, it is passed the trigger settings
+type SpanExporterFactory func(settings map[string]interface{}) (SpanExporter, error)
+
+var exportersMu sync.RWMutex
+var exporters = map[string]SpanExporterFactory{
+	"file": newFileExporter,
+}
+
+// RegisterSpanExporter registers a SpanExporter, which can then be selected with the traceExporter setting
+func RegisterSpanExporter(name string, factory SpanExporterFactory) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+
+	exporters[name] = factory
+}
+
+func newSpanExporter(name string, settings map[string]interface{}) (SpanExporter, error) {
+	exportersMu.RLock()
+	factory, ok := exporters[name]
+	exportersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown trace exporter '%s'", name)
+	}
+
+	return factory(settings)
+}
+
+// fileExporter writes spans as JSON lines to the file set by the traceFile setting
+type fileExporter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newFileExporter(settings map[string]interface{}) (SpanExporter, error) {
+	path, _ := settings["traceFile"].(string)
+	if path == "" {
+		return nil, fmt.Errorf("traceFile must be set to use the file trace exporter")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileExporter{file: file}, nil
+}
+
+func (e *fileExporter) Export(spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.file)
+	for _, span := range spans {
+		if err := enc.Encode(span); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *fileExporter) Close() error {
+	return e.file.Close()
+}
+
+// traceKey is the context key of the trace of a request
+const traceKey contextKey = "trace"
+
+// requestTrace collects the spans of the resolvers executed for a request
+type requestTrace struct {
+	id    string
+	start time.Time
+	end   time.Time
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func newRequestTrace() *requestTrace {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return &requestTrace{id: hex.EncodeToString(id), start: time.Now()}
+}
+
+func (rt *requestTrace) add(span *Span) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	span.TraceID = rt.id
+	rt.spans = append(rt.spans, span)
+}
+
+// apolloTracing formats the trace following https://github.com/apollographql/apollo-tracing
+func (rt *requestTrace) apolloTracing() map[string]interface{} {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	resolvers := make([]interface{}, 0, len(rt.spans))
+	for _, span := range rt.spans {
+		resolvers = append(resolvers, map[string]interface{}{
+			"path":        span.Path,
+			"parentType":  span.ParentType,
+			"fieldName":   span.FieldName,
+			"returnType":  span.ReturnType,
+			"startOffset": span.Start.Sub(rt.start).Nanoseconds(),
+			"duration":    span.Duration.Nanoseconds(),
+		})
+	}
+
+	return map[string]interface{}{
+		"version":   1,
+		"startTime": rt.start.UTC().Format(time.RFC3339Nano),
+		"endTime":   rt.end.UTC().Format(time.RFC3339Nano),
+		"duration":  rt.end.Sub(rt.start).Nanoseconds(),
+		"execution": map[string]interface{}{
+			"resolvers": resolvers,
+		},
+	}
+}
+
+func traceFromContext(ctx context.Context) *requestTrace {
+	if ctx == nil {
+		return nil
+	}
+
+	rt, _ := ctx.Value(traceKey).(*requestTrace)
+	return rt
+}
+
+// traceResolver records a span for each execution of the resolver, when the request is traced
+func traceResolver(handlerID string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		rt := traceFromContext(p.Context)
+		if rt == nil {
+			return resolve(p)
+		}
+
+		span := &Span{
+			FieldName: p.Info.FieldName,
+			HandlerID: handlerID,
+			Start:     time.Now(),
+		}
+
+		if p.Info.Path != nil {
+			span.Path = p.Info.Path.AsArray()
+		}
+		if p.Info.ParentType != nil {
+			span.ParentType = p.Info.ParentType.Name()
+		}
+		if p.Info.ReturnType != nil {
+			span.ReturnType = p.Info.ReturnType.String()
+		}
+
+		result, err := resolve(p)
+
+		span.Duration = time.Since(span.Start)
+		if err != nil {
+			span.Error = err.Error()
+		}
+		rt.add(span)
+
+		return result, err
+	}
+}
@@ -0,0 +1,128 @@
+package graphql
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// recordingExporter records the spans it exports
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *recordingExporter) Export(spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestSpanExporter(t *testing.T) {
+	exporter := &recordingExporter{}
+	RegisterSpanExporter("recording", func(settings map[string]interface{}) (SpanExporter, error) {
+		return exporter, nil
+	})
+	defer func() {
+		exportersMu.Lock()
+		delete(exporters, "recording")
+		exportersMu.Unlock()
+	}()
+
+	trg := newTestTrigger(t, testSettings(t, siblingTypes, siblingSchema, map[string]interface{}{"traceExporter": "recording", "tracing": true}),
+		newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})),
+		newTestHandler("address", func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"error": "no address"}, nil
+		}))
+
+	result := decodeResult(t, postQuery(trg, "", `{ user { name } address { city } }`))
+
+	// One span per field resolved by a handler
+	if len(exporter.spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(exporter.spans))
+	}
+	sort.Slice(exporter.spans, func(i, j int) bool { return exporter.spans[i].FieldName > exporter.spans[j].FieldName })
+
+	tests := []struct {
+		fieldName string
+		handlerID string
+		err       string
+	}{
+		{"user", "test/user", ""},
+		{"address", "test/address", "no address"},
+	}
+	for i, test := range tests {
+		span := exporter.spans[i]
+		if span.FieldName != test.fieldName || span.HandlerID != test.handlerID || span.ParentType != "Query" || span.Error != test.err {
+			t.Errorf("got span %+v, want field '%s' of Query, handler '%s' and error '%s'", span, test.fieldName, test.handlerID, test.err)
+		}
+		if span.TraceID == "" || span.TraceID != exporter.spans[0].TraceID {
+			t.Errorf("the span of '%s' is not part of the trace of the request", span.FieldName)
+		}
+	}
+
+	// The tracing extension holds the same resolvers
+	extensions, _ := result["extensions"].(map[string]interface{})
+	tracing, _ := extensions["tracing"].(map[string]interface{})
+	execution, _ := tracing["execution"].(map[string]interface{})
+	if resolvers, _ := execution["resolvers"].([]interface{}); len(resolvers) != 2 {
+		t.Errorf("unexpected tracing extension %v", extensions)
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	if _, err := newSpanExporter("file", map[string]interface{}{}); err == nil {
+		t.Error("expected an error without traceFile")
+	}
+	if _, err := newSpanExporter("unknown", map[string]interface{}{}); err == nil {
+		t.Error("expected an error for an unknown exporter")
+	}
+
+	dir, err := ioutil.TempDir("", "tracing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "spans.json")
+	exporter, err := newSpanExporter("file", map[string]interface{}{"traceFile": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := []*Span{{TraceID: "1", FieldName: "user"}, {TraceID: "1", FieldName: "address", Error: "no address"}}
+	if err := exporter.Export(spans); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.(*fileExporter).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []*Span
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		span := &Span{}
+		if err := json.Unmarshal(scanner.Bytes(), span); err != nil {
+			t.Fatalf("invalid line %s: %v", scanner.Text(), err)
+		}
+		lines = append(lines, span)
+	}
+
+	if len(lines) != 2 || lines[0].FieldName != "user" || lines[1].Error != "no address" {
+		t.Errorf("unexpected spans %+v", lines)
+	}
+}
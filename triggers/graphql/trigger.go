@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/TIBCOSoftware/flogo-contrib/trigger/rest/cors"
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
 	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
 	"github.com/TIBCOSoftware/flogo-lib/logger"
 	"github.com/graphql-go/graphql"
@@ -18,19 +23,48 @@ import (
 
 const (
 	REST_CORS_PREFIX = "GRAPHQL_TRIGGER"
+
+	// stopTimeout bounds the time Stop waits for the requests in flight to finish
+	stopTimeout = 10 * time.Second
 )
 
 // log is the default package logger
 var log = logger.GetLogger("trigger-flogo-graphql")
 
-var gqlObjects map[string]*graphql.Object
-var graphQlSchema *graphql.Schema
+// contextKey is the type of the keys of the values the trigger sets in the context of a request
+type contextKey string
 
 // GraphQLTrigger REST trigger struct
 type GraphQLTrigger struct {
 	metadata *trigger.Metadata
 	server   *Server
 	config   *trigger.Config
+	handlers []*trigger.Handler
+
+	schemaMu  sync.RWMutex
+	schemas   map[string]*graphql.Schema
+	stopWatch chan struct{}
+
+	tracing  bool
+	exporter SpanExporter
+	mock     bool
+	remote   *remoteSchema
+
+	statusPolicy string
+	maskErrors   bool
+
+	semaphore           chan struct{}
+	handlerSemaphores   map[*trigger.Handler]chan struct{}
+	resolverTimeout     time.Duration
+	handlerTimeouts     map[*trigger.Handler]time.Duration
+	handlerDependencies map[*trigger.Handler][]string
+
+	listening  int32
+	draining   int32
+	drainDelay time.Duration
+
+	maxBodySize    int64
+	maxQueryLength int
 }
 
 //NewFactory create a new Trigger factory
@@ -60,105 +94,591 @@ func (t *GraphQLTrigger) Initialize(ctx trigger.InitContext) error {
 		return fmt.Errorf("no Settings found for trigger '%s'", t.config.Id)
 	}
 
-	if _, ok := t.config.Settings["port"]; !ok {
+	socketPath := t.config.GetSetting("socketPath")
+	if _, ok := t.config.Settings["port"]; !ok && socketPath == "" {
 		return fmt.Errorf("no Port found for trigger '%s' in settings", t.config.Id)
 	}
 
-	addr := ":" + t.config.GetSetting("port")
+	addr := net.JoinHostPort(t.config.GetSetting("host"), t.config.GetSetting("port"))
+
+	if tracing, ok := t.config.Settings["tracing"]; ok {
+		t.tracing, _ = data.CoerceToBoolean(tracing)
+	}
+
+	if mock, ok := t.config.Settings["mock"]; ok {
+		t.mock, _ = data.CoerceToBoolean(mock)
+	}
+
+	t.statusPolicy = statusPolicyIgnore
+	if policy := t.config.GetSetting("statusPolicy"); policy != "" {
+		if policy != statusPolicyIgnore && policy != statusPolicyAny && policy != statusPolicyAll {
+			return fmt.Errorf("invalid statusPolicy '%s' for trigger '%s'", policy, t.config.Id)
+		}
+		t.statusPolicy = policy
+	}
+
+	if maskErrors, ok := t.config.Settings["maskErrors"]; ok {
+		t.maskErrors, _ = data.CoerceToBoolean(maskErrors)
+	}
+
+	if name := t.config.GetSetting("traceExporter"); name != "" {
+		exporter, err := newSpanExporter(name, t.config.Settings)
+		if err != nil {
+			return fmt.Errorf("unable to create the trace exporter for trigger '%s': %v", t.config.Id, err)
+		}
+		t.exporter = exporter
+	}
+
+	if err := t.configureRemote(); err != nil {
+		return fmt.Errorf("invalid settings for trigger '%s': %v", t.config.Id, err)
+	}
+
+	path := t.config.GetSetting("path")
+	switch t.config.GetSetting("tenantSelector") {
+	case "", tenantByHeader, tenantByHost:
+	case tenantByPath:
+		path = "/:" + tenantParam + path
+	default:
+		return fmt.Errorf("invalid tenantSelector '%s' for trigger '%s'", t.config.GetSetting("tenantSelector"), t.config.Id)
+	}
+
+	var err error
+	if t.semaphore, err = newSemaphore(t.config.Settings["maxConcurrency"]); err != nil {
+		return fmt.Errorf("invalid settings for trigger '%s': %v", t.config.Id, err)
+	}
+	if t.resolverTimeout, err = parseTimeout("resolverTimeout", t.config.Settings["resolverTimeout"]); err != nil {
+		return fmt.Errorf("invalid settings for trigger '%s': %v", t.config.Id, err)
+	}
+	if t.drainDelay, err = parseTimeout("drainDelay", t.config.Settings["drainDelay"]); err != nil {
+		return fmt.Errorf("invalid settings for trigger '%s': %v", t.config.Id, err)
+	}
+
+	t.handlers = ctx.GetHandlers()
+	t.handlerSemaphores = make(map[*trigger.Handler]chan struct{})
+	t.handlerTimeouts = make(map[*trigger.Handler]time.Duration)
+	t.handlerDependencies = make(map[*trigger.Handler][]string)
+	fieldDependencies := make(map[string][]string)
+	for _, handler := range t.handlers {
+		limit, _ := handler.GetSetting("maxConcurrency")
+		if t.handlerSemaphores[handler], err = newSemaphore(limit); err != nil {
+			return fmt.Errorf("invalid settings for handler '%s': %v", t.handlerID(handler), err)
+		}
+		timeout, _ := handler.GetSetting("timeout")
+		if t.handlerTimeouts[handler], err = parseTimeout("timeout", timeout); err != nil {
+			return fmt.Errorf("invalid settings for handler '%s': %v", t.handlerID(handler), err)
+		}
+		dependsOn, _ := handler.GetSetting("dependsOn")
+		if t.handlerDependencies[handler], err = parseDependsOn(dependsOn); err != nil {
+			return fmt.Errorf("invalid settings for handler '%s': %v", t.handlerID(handler), err)
+		}
+		field := handler.GetStringSetting("resolverFor")
+		fieldDependencies[field] = append(fieldDependencies[field], t.handlerDependencies[handler]...)
+	}
+	if err := checkDependencies(fieldDependencies); err != nil {
+		return fmt.Errorf("invalid settings for trigger '%s': %v", t.config.Id, err)
+	}
 
-	// Build the GraphQL Object Types & Schemas
-	t.buildGraphQLObjects()
-	graphQlSchema = t.buildGraphQLSchema(ctx.GetHandlers())
+	// Build the GraphQL Object Types & Schemas, one for each tenant
+	t.schemas = make(map[string]*graphql.Schema)
+	for _, tenant := range t.tenantIDs() {
+		schema, err := t.buildSchema(tenant)
+		if err != nil {
+			if tenant != "" {
+				return fmt.Errorf("unable to build the GraphQL schema of tenant '%s' for trigger '%s': %v", tenant, t.config.Id, err)
+			}
+			return fmt.Errorf("unable to build the GraphQL schema for trigger '%s': %v", t.config.Id, err)
+		}
+		t.setSchema(tenant, schema)
+	}
 
 	// Setup routes for the path & verb
-	router.Handle("GET", t.config.GetSetting("path"), newActionHandler(t))
-	router.Handle("POST", t.config.GetSetting("path"), newActionHandler(t))
+	router.Handle("GET", path, newActionHandler(t))
+	router.Handle("POST", path, newActionHandler(t))
+
+	t.server = NewServer(addr, t.probeHandler(router))
+	if socketPath != "" {
+		log.Debugf("Configured on socket %s", socketPath)
+		t.server.socketPath = socketPath
+	} else {
+		log.Debugf("Configured on %s", addr)
+	}
+	if err := t.configureLimits(); err != nil {
+		return fmt.Errorf("invalid settings for trigger '%s': %v", t.config.Id, err)
+	}
+	if t.server.accessLog, err = newAccessLog(t.config.Settings); err != nil {
+		return fmt.Errorf("invalid settings for trigger '%s': %v", t.config.Id, err)
+	}
 
-	log.Debugf("Configured on port %s", t.config.Settings["port"])
-	t.server = NewServer(addr, router)
+	if compress, _ := data.CoerceToBoolean(t.config.Settings["compression"]); compress {
+		minSize, err := parseLimit("compressionMinSize", t.config.Settings["compressionMinSize"], defaultCompressionMinSize)
+		if err != nil {
+			return fmt.Errorf("invalid settings for trigger '%s': %v", t.config.Id, err)
+		}
+		t.server.Handler = compressHandler(t.server.Handler, int(minSize))
+	}
+
+	t.server.h2c, _ = data.CoerceToBoolean(t.config.Settings["h2c"])
+	t.server.certFile = t.config.GetSetting("certFile")
+	t.server.keyFile = t.config.GetSetting("keyFile")
+	if (t.server.certFile == "") != (t.server.keyFile == "") {
+		return fmt.Errorf("both certFile and keyFile must be set to enable TLS for trigger '%s'", t.config.Id)
+	}
 
 	return nil
 }
 
-func (t *GraphQLTrigger) buildGraphQLObjects() {
-	gqlTypes := t.config.Settings["types"].([]interface{})
+// currentSchema returns the schema of the tenant which new requests are executed against, nil if the tenant is unknown
+func (t *GraphQLTrigger) currentSchema(tenant string) *graphql.Schema {
+	t.schemaMu.RLock()
+	defer t.schemaMu.RUnlock()
+
+	return t.schemas[tenant]
+}
+
+func (t *GraphQLTrigger) setSchema(tenant string, schema *graphql.Schema) {
+	t.schemaMu.Lock()
+	defer t.schemaMu.Unlock()
+
+	t.schemas[tenant] = schema
+}
+
+// buildSchema builds the GraphQL schema of a tenant from its schema file if one is configured, otherwise from its
+// types & schema. The empty tenant builds the default schema of the trigger settings.
+func (t *GraphQLTrigger) buildSchema(tenant string) (*graphql.Schema, error) {
+	gqlTypes, fSchema, path, err := t.tenantSource(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		def, err := readSchemaFile(path)
+		if err != nil {
+			return nil, err
+		}
+		gqlTypes, fSchema = def.Types, def.Schema
+	}
+
+	if gqlTypes == nil || fSchema == nil {
+		return nil, fmt.Errorf("both types and schema must be supplied")
+	}
+
+	gqlObjects, err := t.buildGraphQLObjects(gqlTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var remoteFields graphql.Fields
+	if t.remote != nil {
+		if remoteFields, err = t.remote.buildFields(gqlObjects); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.buildGraphQLSchema(fSchema, gqlObjects, remoteFields, t.tenantHandlers(tenant))
+}
 
+func (t *GraphQLTrigger) buildGraphQLObjects(gqlTypes []interface{}) (map[string]graphql.Output, error) {
 	// Create type objects
-	gqlObjects = make(map[string]*graphql.Object)
+	gqlObjects := make(map[string]graphql.Output)
+	objects := make(map[string]*graphql.Object)
 
-	// Get the graphql types
+	var typDefs []map[string]interface{}
 	for _, typ := range gqlTypes {
-		lTyp := lower(typ)
-		typ := lTyp.(map[string]interface{})
-		name := typ["name"].(string)
-		fields := make(graphql.Fields)
+		typDef, ok := lower(typ).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid type definition: %v", typ)
+		}
+		typDefs = append(typDefs, typDef)
+	}
+
+	// Enums are built first, as fields may be of their type
+	for _, typDef := range typDefs {
+		if !strings.EqualFold(typeKind(typDef), "enum") {
+			continue
+		}
+
+		name, _ := typDef["name"].(string)
+		description, _ := typDef["description"].(string)
+		values, err := buildEnumValues(name, typDef)
+		if err != nil {
+			return nil, err
+		}
+
+		gqlObjects[name] = graphql.NewEnum(
+			graphql.EnumConfig{
+				Name:        name,
+				Description: description,
+				Values:      values,
+			})
+	}
+
+	// Then interfaces, as objects may implement them
+	for _, typDef := range typDefs {
+		if !strings.EqualFold(typeKind(typDef), "interface") {
+			continue
+		}
+
+		name, _ := typDef["name"].(string)
+		description, _ := typDef["description"].(string)
+		fields, err := buildFields(name, typDef, gqlObjects)
+		if err != nil {
+			return nil, err
+		}
+
+		discriminator, _ := typDef["discriminator"].(string)
+		gqlObjects[name] = graphql.NewInterface(
+			graphql.InterfaceConfig{
+				Name:        name,
+				Description: description,
+				Fields:      fields,
+				ResolveType: resolveAbstractType(objects, discriminator),
+			})
+	}
+
+	// Get the graphql types
+	for _, typDef := range typDefs {
+		if !strings.EqualFold(typeKind(typDef), "object") {
+			continue
+		}
 
-		for k, f := range typ["fields"].(map[string]interface{}) {
-			fTyp := f.(map[string]interface{})
+		name, _ := typDef["name"].(string)
+		description, _ := typDef["description"].(string)
+		fields, err := buildFields(name, typDef, gqlObjects)
+		if err != nil {
+			return nil, err
+		}
 
-			fields[k] = &graphql.Field{
-				Type: coerceType(fTyp["type"].(string)),
+		var interfaces []*graphql.Interface
+		ifaceNames, _ := typDef["interfaces"].([]interface{})
+		for _, ifaceName := range ifaceNames {
+			iface, ok := gqlObjects[fmt.Sprint(ifaceName)].(*graphql.Interface)
+			if !ok {
+				return nil, fmt.Errorf("type '%s' implements unknown interface '%v'", name, ifaceName)
 			}
+
+			// Fields of the interface don't have to be repeated by the object
+			for k, f := range iface.Fields() {
+				if _, ok := fields[k]; !ok {
+					fields[k] = &graphql.Field{Type: f.Type, Description: f.Description, DeprecationReason: f.DeprecationReason, Resolve: f.Resolve}
+				}
+			}
+			interfaces = append(interfaces, iface)
 		}
 
 		obj := graphql.NewObject(
 			graphql.ObjectConfig{
-				Name:   name,
-				Fields: fields,
+				Name:        name,
+				Description: description,
+				Fields:      fields,
+				Interfaces:  interfaces,
 			})
 
+		objects[name] = obj
 		gqlObjects[name] = obj
 	}
+
+	// Unions are built last, as they are composed of objects
+	for _, typDef := range typDefs {
+		switch kind := strings.ToLower(typeKind(typDef)); kind {
+		case "enum", "interface", "object":
+			continue
+		case "union":
+		default:
+			return nil, fmt.Errorf("unknown kind '%s' for type '%v'", kind, typDef["name"])
+		}
+
+		name, _ := typDef["name"].(string)
+		description, _ := typDef["description"].(string)
+
+		var members []*graphql.Object
+		memberNames, _ := typDef["types"].([]interface{})
+		for _, memberName := range memberNames {
+			obj, ok := objects[fmt.Sprint(memberName)]
+			if !ok {
+				return nil, fmt.Errorf("union '%s' includes unknown type '%v'", name, memberName)
+			}
+			members = append(members, obj)
+		}
+
+		discriminator, _ := typDef["discriminator"].(string)
+		gqlObjects[name] = graphql.NewUnion(
+			graphql.UnionConfig{
+				Name:        name,
+				Description: description,
+				Types:       members,
+				ResolveType: resolveAbstractType(objects, discriminator),
+			})
+	}
+
+	return gqlObjects, nil
+}
+
+// buildFields builds the fields of an object or interface type definition
+func buildFields(name string, typDef map[string]interface{}, gqlObjects map[string]graphql.Output) (graphql.Fields, error) {
+	fields := make(graphql.Fields)
+
+	fieldDefs, _ := typDef["fields"].(map[string]interface{})
+	for k, f := range fieldDefs {
+		fTyp, ok := f.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid definition for field '%s' of type '%s'", k, name)
+		}
+		typName, _ := fTyp["type"].(string)
+		description, _ := fTyp["description"].(string)
+		deprecationReason, _ := fTyp["deprecationreason"].(string)
+
+		directiveDefs, _ := fTyp["directives"].([]interface{})
+		directives, err := buildDirectives(k, directiveDefs)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[k] = &graphql.Field{
+			Type:              resolveType(typName, gqlObjects),
+			Description:       description,
+			DeprecationReason: deprecationReason,
+			Resolve:           directiveResolver(directives, nil),
+		}
+	}
+
+	return fields, nil
+}
+
+// buildArgs builds the arguments of a field from their definitions
+func buildArgs(fieldName string, argDefs map[string]interface{}, gqlObjects map[string]graphql.Output) (graphql.FieldConfigArgument, error) {
+	args := make(graphql.FieldConfigArgument)
+
+	for k, v := range argDefs {
+		argTyp, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid definition for argument '%s' of field '%s'", k, fieldName)
+		}
+		typName, _ := argTyp["type"].(string)
+		description, _ := argTyp["description"].(string)
+
+		typ := resolveInputType(typName, gqlObjects)
+		arg := &graphql.ArgumentConfig{
+			Type:        typ,
+			Description: description,
+		}
+
+		if defaultValue, ok := argTyp["defaultvalue"]; ok {
+			// Default values are parsed like variables, e.g. a JSON number becomes an int for an Int argument
+			switch typ := typ.(type) {
+			case *graphql.Scalar:
+				arg.DefaultValue = typ.ParseValue(defaultValue)
+			case *graphql.Enum:
+				arg.DefaultValue = typ.ParseValue(defaultValue)
+			default:
+				arg.DefaultValue = defaultValue
+			}
+
+			if arg.DefaultValue == nil {
+				return nil, fmt.Errorf("invalid default value '%v' for argument '%s' of field '%s'", defaultValue, k, fieldName)
+			}
+		}
+
+		args[k] = arg
+	}
+
+	return args, nil
+}
+
+// buildEnumValues builds the values of an enum type definition. Values are either names, or objects holding the
+// name along with its description and deprecation reason.
+func buildEnumValues(name string, typDef map[string]interface{}) (graphql.EnumValueConfigMap, error) {
+	values := make(graphql.EnumValueConfigMap)
+
+	valueDefs, _ := typDef["values"].([]interface{})
+	for _, v := range valueDefs {
+		switch v := v.(type) {
+		case string:
+			values[v] = &graphql.EnumValueConfig{Value: v}
+		case map[string]interface{}:
+			valueName, _ := v["name"].(string)
+			if valueName == "" {
+				return nil, fmt.Errorf("missing name for a value of enum '%s'", name)
+			}
+			description, _ := v["description"].(string)
+			deprecationReason, _ := v["deprecationreason"].(string)
+
+			values[valueName] = &graphql.EnumValueConfig{
+				Value:             valueName,
+				Description:       description,
+				DeprecationReason: deprecationReason,
+			}
+		default:
+			return nil, fmt.Errorf("invalid value '%v' for enum '%s'", v, name)
+		}
+	}
+
+	return values, nil
+}
+
+// typeKind returns the kind of a type definition, types are objects unless specified otherwise
+func typeKind(typDef map[string]interface{}) string {
+	if kind, ok := typDef["kind"].(string); ok && kind != "" {
+		return kind
+	}
+
+	return "object"
+}
+
+// resolveAbstractType resolves the concrete object type of an interface or union value from its __typename key, or
+// else from its discriminator field
+func resolveAbstractType(objects map[string]*graphql.Object, discriminator string) graphql.ResolveTypeFn {
+
+	return func(p graphql.ResolveTypeParams) *graphql.Object {
+
+		value, ok := p.Value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		name, _ := value["__typename"].(string)
+		if name == "" && discriminator != "" {
+			name, _ = value[discriminator].(string)
+		}
+
+		return objects[name]
+	}
 }
 
-func (t *GraphQLTrigger) buildGraphQLSchema(handlers []*trigger.Handler) *graphql.Schema {
-	fSchema := t.config.Settings["schema"].(map[string]interface{})
+func (t *GraphQLTrigger) buildGraphQLSchema(fSchema map[string]interface{}, gqlObjects map[string]graphql.Output, remoteFields graphql.Fields, handlers []*trigger.Handler) (*graphql.Schema, error) {
 	fSchema = lower(fSchema).(map[string]interface{})
 
 	// Build the graphql schema
 	var schema graphql.Schema
 	var queryType *graphql.Object
 
-	if strings.EqualFold(t.config.Settings["operation"].(string), "query") {
+	if strings.EqualFold(t.config.GetSetting("operation"), "query") {
 
 		var objName string
 		queryFields := make(graphql.Fields)
+		connTypes := newConnectionTypes(gqlObjects)
+
+		var fixtures map[string]interface{}
+		if path := t.config.GetSetting("mockFixtures"); t.mock && path != "" {
+			var err error
+			if fixtures, err = readMockFixtures(path); err != nil {
+				return nil, err
+			}
+		}
+
+		query, ok := fSchema["query"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("no query found in schema")
+		}
 
 		// Get the object name
-		for k, v := range fSchema["query"].(map[string]interface{}) {
+		for k, v := range query {
 			if strings.EqualFold(k, "name") {
-				objName = v.(string)
+				objName, _ = v.(string)
 			} else if strings.EqualFold(k, "fields") {
-				qf := v.(map[string]interface{})
+				qf, _ := v.(map[string]interface{})
 
 				for k, v := range qf {
 
 					// Grab query args
-					argObj := v.(map[string]interface{})
-					args := make(graphql.FieldConfigArgument)
+					argObj, ok := v.(map[string]interface{})
+					if !ok {
+						return nil, fmt.Errorf("invalid definition for query field '%s'", k)
+					}
+					argDefs, _ := argObj["args"].(map[string]interface{})
+					args, err := buildArgs(k, argDefs, gqlObjects)
+					if err != nil {
+						return nil, err
+					}
 
-					for k, v := range argObj["args"].(map[string]interface{}) {
+					// The field type defaults to the object type named after the field
+					var fieldType graphql.Output = gqlObjects[k]
+					typName, _ := argObj["type"].(string)
+					if typ := resolveType(typName, gqlObjects); typ != nil {
+						fieldType = typ
+					}
 
-						argTyp := v.(map[string]interface{})
-						args[k] = &graphql.ArgumentConfig{
-							Type: coerceType(argTyp["type"].(string)),
+					var listType graphql.Output
+					connection, _ := argObj["connection"].(bool)
+					if connection {
+						list, ok := fieldType.(*graphql.List)
+						if !ok {
+							return nil, fmt.Errorf("connection field '%s' must be a list type", k)
 						}
+						listType = list
+						if fieldType, err = connTypes.connectionFor(list.OfType); err != nil {
+							return nil, err
+						}
+						addConnectionArgs(args)
+					}
+
+					directiveDefs, _ := argObj["directives"].([]interface{})
+					directives, err := buildDirectives(k, directiveDefs)
+					if err != nil {
+						return nil, err
+					}
+
+					constraints, err := buildArgConstraints(k, argDefs)
+					if err != nil {
+						return nil, err
 					}
 
+					var resolver graphql.FieldResolveFn
 					for _, handler := range handlers {
 						if strings.EqualFold(handler.GetStringSetting("resolverFor"), k) {
-							// Build the queryField
-							queryFields[k] = &graphql.Field{
-								Type:    gqlObjects[k],
-								Args:    args,
-								Resolve: fieldResolver(handler),
+							resolver = fieldResolver(handler)
+							if connection {
+								resolver = connectionResolver(handler)
+							}
+							resolver = traceResolver(t.handlerID(handler), directiveResolver(directives, validatingResolver(constraints, resolver)))
+							if t.maskErrors {
+								resolver = maskingResolver(t.handlerID(handler), resolver)
 							}
+							resolver = t.concurrentResolver(handler, resolver)
+						}
+					}
+
+					if resolver == nil {
+						// The field of the remote schema is merged below, rather than mocked
+						if _, ok := remoteFields[k]; ok {
+							log.Infof("No handler found to resolve field '%s', it is resolved by the remote schema", k)
+							continue
 						}
+						if !t.mock {
+							log.Warnf("No handler found to resolve field '%s', it is left out of the schema", k)
+							continue
+						}
+						resolver = directiveResolver(directives, validatingResolver(constraints, mockResolver(fixtures, listType)))
+						if t.maskErrors {
+							resolver = maskingResolver("mock", resolver)
+						}
+					}
+
+					// Build the queryField
+					description, _ := argObj["description"].(string)
+					deprecationReason, _ := argObj["deprecationreason"].(string)
+					queryFields[k] = &graphql.Field{
+						Type:              fieldType,
+						Args:              args,
+						Resolve:           resolver,
+						Description:       description,
+						DeprecationReason: deprecationReason,
 					}
 				}
 			}
 		}
 
+		// Fields of the remote schema are merged in, unless resolved locally
+		for k, f := range remoteFields {
+			if _, ok := queryFields[k]; ok {
+				log.Warnf("Field '%s' of the remote schema is resolved by its local handler instead", k)
+				continue
+			}
+			if t.maskErrors {
+				f.Resolve = maskingResolver("remote", f.Resolve)
+			}
+			queryFields[k] = f
+		}
+
 		queryType = graphql.NewObject(
 			graphql.ObjectConfig{
 				Name:   objName,
@@ -166,21 +686,94 @@ func (t *GraphQLTrigger) buildGraphQLSchema(handlers []*trigger.Handler) *graphq
 			})
 	}
 
-	schema, _ = graphql.NewSchema(
+	// Register all the types, objects implementing an interface may not be reachable from the query
+	var types []graphql.Type
+	for _, typ := range gqlObjects {
+		types = append(types, typ)
+	}
+
+	schema, err := graphql.NewSchema(
 		graphql.SchemaConfig{
-			Query: queryType,
+			Query:      queryType,
+			Types:      types,
+			Directives: schemaDirectives(),
 		})
+	if err != nil {
+		return nil, err
+	}
 
-	return &schema
+	return &schema, nil
 }
 
 func (t *GraphQLTrigger) Start() error {
-	return t.server.Start()
+	interval, err := time.ParseDuration(t.config.GetSetting("schemaWatchInterval"))
+	if err != nil || interval <= 0 {
+		interval = defaultSchemaWatchInterval
+	}
+
+	t.stopWatch = make(chan struct{})
+	for _, tenant := range t.tenantIDs() {
+		if _, _, path, _ := t.tenantSource(tenant); path != "" {
+			go t.watchSchemaFile(tenant, path, interval, t.stopWatch)
+		}
+	}
+
+	if err := t.server.Start(); err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&t.draining, 0)
+	atomic.StoreInt32(&t.listening, 1)
+
+	return nil
+}
+
+// ServeHTTP serves a request as the server of the trigger does, without the trigger listening, e.g. in tests
+func (t *GraphQLTrigger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.server.Handler.ServeHTTP(w, r)
 }
 
 // Stop implements util.Managed.Stop
 func (t *GraphQLTrigger) Stop() error {
-	return t.server.Stop()
+	// Fail the readiness probe first, so that no new requests are routed to the trigger while it drains
+	atomic.StoreInt32(&t.draining, 1)
+	if t.drainDelay > 0 {
+		log.Infof("Draining trigger '%s' for %v", t.config.Id, t.drainDelay)
+		time.Sleep(t.drainDelay)
+	}
+
+	if t.stopWatch != nil {
+		close(t.stopWatch)
+		t.stopWatch = nil
+	}
+
+	err := t.server.Stop()
+	if err == nil {
+		// The requests in flight still trace and log, so the exporter and access log are closed once they finished
+		if waitErr := t.server.WaitStop(stopTimeout); waitErr != nil {
+			log.Warnf("Trigger '%s' stopped before all requests finished: %v", t.config.Id, waitErr)
+		}
+	}
+	atomic.StoreInt32(&t.listening, 0)
+
+	if closer, ok := t.exporter.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Errorf("Unable to close the trace exporter: %v", err)
+		}
+	}
+
+	if t.server.accessLog != nil {
+		if err := t.server.accessLog.Close(); err != nil {
+			log.Errorf("Unable to close the access log: %v", err)
+		}
+	}
+
+	return err
+}
+
+// handlerID identifies a handler of the trigger by the field it resolves
+func (t *GraphQLTrigger) handlerID(handler *trigger.Handler) string {
+	return t.config.Id + "/" + handler.GetStringSetting("resolverFor")
 }
 
 func fieldResolver(handler *trigger.Handler) graphql.FieldResolveFn {
@@ -188,11 +781,20 @@ func fieldResolver(handler *trigger.Handler) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (interface{}, error) {
 
 		triggerData := map[string]interface{}{
-			"args": p.Args,
+			"args":   p.Args,
+			"tenant": tenantFromContext(p.Context),
+		}
+
+		results, err := handle(p.Context, handler, triggerData)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := replyError(results); err != nil {
+			return nil, err
 		}
 
-		results, err := handler.Handle(context.Background(), triggerData)
-		return results["data"].Value(), err
+		return replyValue(results, "data"), nil
 	}
 
 }
@@ -215,71 +817,180 @@ func newActionHandler(rt *GraphQLTrigger) httprouter.Handle {
 		c := cors.New(REST_CORS_PREFIX, log)
 		c.WriteCorsActualRequestHeaders(w)
 
-		queryValues := r.URL.Query()
-		queryParams := make(map[string]string, len(queryValues))
-		header := make(map[string]string, len(r.Header))
-
-		for key, value := range r.Header {
-			header[key] = strings.Join(value, ",")
-		}
-
-		for key, value := range queryValues {
-			queryParams[key] = strings.Join(value, ",")
+		respType, err := negotiateResponseType(r.Header.Get("Accept"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotAcceptable)
+			return
 		}
 
-		var query string
+		var gqlReq *graphQLRequest
 
 		httpVerb := strings.ToUpper(r.Method)
-		if val, ok := queryParams["query"]; ok && strings.EqualFold(httpVerb, "GET") {
-			query = val
+		if strings.EqualFold(httpVerb, "GET") {
+			gqlReq, err = parseValues(r.URL.Query())
 		} else if strings.EqualFold(httpVerb, "POST") {
-			// Check the HTTP Header Content-Type
-			contentType := r.Header.Get("Content-Type")
-			if !strings.EqualFold(contentType, "application/json") {
-				err := fmt.Errorf("%v", "Invalid content type. Must be application/json for POST methods.")
-				http.Error(w, err.Error(), http.StatusBadRequest)
-				return
-			}
-			var content interface{}
-			err := json.NewDecoder(r.Body).Decode(&content)
-			if err != nil {
-				switch {
-				case err == io.EOF:
-					// empty body
-					//todo should handler say if content is expected?
-				case err != nil:
-					http.Error(w, err.Error(), http.StatusBadRequest)
+			if rt.maxBodySize > 0 {
+				if r.ContentLength > rt.maxBodySize {
+					http.Error(w, fmt.Sprintf("Request body exceeds the limit of %d bytes.", rt.maxBodySize), http.StatusRequestEntityTooLarge)
 					return
 				}
+				r.Body = http.MaxBytesReader(w, r.Body, rt.maxBodySize)
 			}
-			jsonContent := content.(map[string]interface{})
-			query = jsonContent["query"].(string)
+			gqlReq, err = parsePostRequest(r)
 		} else {
-			err := fmt.Errorf("%v", "HTTP GET and POST are the only supported verbs.")
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			err = newRequestError(http.StatusMethodNotAllowed, "%v", "HTTP GET and POST are the only supported verbs.")
+		}
+
+		if err != nil {
+			status := http.StatusBadRequest
+			if reqErr, ok := err.(*requestError); ok {
+				status = reqErr.status
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if gqlReq.Query == "" {
+			http.Error(w, "No query supplied.", http.StatusBadRequest)
+			return
+		}
+
+		entry := accessEntryFromContext(r.Context())
+		if entry != nil {
+			entry.OperationName = gqlReq.OperationName
+			entry.QueryHash = queryHash(gqlReq.Query)
+		}
+
+		if rt.maxQueryLength > 0 && len(gqlReq.Query) > rt.maxQueryLength {
+			status := http.StatusRequestEntityTooLarge
+			if strings.EqualFold(httpVerb, "GET") {
+				status = http.StatusRequestURITooLong
+			}
+			http.Error(w, fmt.Sprintf("Query exceeds the limit of %d characters.", rt.maxQueryLength), status)
+			return
+		}
+
+		// The query is parsed once, errors are reported once the schema is known, as graphql.Do does
+		doc, errs := parseQuery(gqlReq.Query)
+		operation := operationType(doc, gqlReq.OperationName)
+
+		// Mutations have side effects, so they must not be run by a safe method
+		if strings.EqualFold(httpVerb, "GET") && operation == "mutation" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "Mutations are only supported via HTTP POST.", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx := r.Context()
+
+		var reqTrace *requestTrace
+		if rt.tracing || rt.exporter != nil {
+			reqTrace = newRequestTrace()
+			ctx = context.WithValue(ctx, traceKey, reqTrace)
+		}
+
+		// finishTrace ends the trace of the request, and returns the extensions holding it
+		finishTrace := func() map[string]interface{} {
+			if reqTrace == nil {
+				return nil
+			}
+
+			reqTrace.end = time.Now()
+
+			if rt.exporter != nil {
+				if err := rt.exporter.Export(reqTrace.spans); err != nil {
+					log.Errorf("Unable to export the trace of request '%s': %v", reqTrace.id, err)
+				}
+			}
+
+			if !rt.tracing {
+				return nil
+			}
+			return map[string]interface{}{"tracing": reqTrace.apolloTracing()}
+		}
+
+		tenant := rt.tenantFor(r, ps)
+		if entry != nil {
+			entry.Tenant = tenant
+		}
+
+		current := rt.currentSchema(tenant)
+		if current == nil {
+			if tenant == "" {
+				http.Error(w, "No tenant supplied.", http.StatusNotFound)
+			} else {
+				http.Error(w, fmt.Sprintf("Unknown tenant '%s'.", tenant), http.StatusNotFound)
+			}
 			return
 		}
+		schema := *current
+		ctx = context.WithValue(ctx, tenantKey, tenant)
+
+		if errs == nil {
+			if validation := graphql.ValidateDocument(&schema, doc, nil); !validation.IsValid {
+				errs = validation.Errors
+			}
+		}
+
+		// Queries using @defer or @stream are delivered incrementally to clients accepting multipart responses
+		if errs == nil && acceptsMultipart(r.Header.Get("Accept")) {
+			if plan := incrementalPlanFor(doc, gqlReq); plan != nil {
+				rt.serveIncremental(ctx, w, schema, plan, gqlReq.OperationName, finishTrace)
+				return
+			}
+		}
 
 		// Process the request
-		result := graphql.Do(graphql.Params{
-			Schema:        *graphQlSchema,
-			RequestString: query,
-		})
+		result := &graphql.Result{Errors: errs}
+		if errs == nil {
+			result = graphql.Execute(graphql.ExecuteParams{
+				Schema:        schema,
+				AST:           doc,
+				OperationName: gqlReq.OperationName,
+				Args:          gqlReq.Variables,
+				Context:       withRequestContext(ctx, newRequestStore(), doc, gqlReq.OperationName, gqlReq.Variables),
+			})
+			restoreExtensions(result)
+		}
+
+		if extensions := finishTrace(); len(extensions) > 0 {
+			if result.Extensions == nil {
+				result.Extensions = make(map[string]interface{})
+			}
+			for k, v := range extensions {
+				result.Extensions[k] = v
+			}
+		}
 
 		if len(result.Errors) > 0 {
 			log.Debugf("GraphQL Trigger Error: %#v", result.Errors)
-			http.Error(w, result.Errors[0].Error(), http.StatusBadRequest)
+		}
+
+		// Per GraphQL over HTTP, a result without data means the request failed before execution, which is a 4xx status
+		// for application/graphql-response+json clients only, application/json clients receive a 200 status
+		status := http.StatusOK
+		if result.Data == nil && len(result.Errors) > 0 {
+			if respType == mediaTypeGraphQLResponse {
+				status = http.StatusBadRequest
+			}
+		} else if fieldStatus := responseStatus(rt.statusPolicy, result); fieldStatus != 0 {
+			status = fieldStatus
+		}
+
+		if respType == mediaTypeMultipart {
+			writeSinglePart(w, status, result)
 			return
 		}
 
-		if result.Data != nil {
+		if respType == mediaTypeGraphQLResponse {
+			w.Header().Set("Content-Type", mediaTypeGraphQLResponse+"; charset=utf-8")
+		} else {
 			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-			w.WriteHeader(http.StatusOK)
+		}
+		w.WriteHeader(status)
 
-			if err := json.NewEncoder(w).Encode(result); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				log.Error(err)
-			}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Error(err)
 		}
 	}
 }
@@ -302,6 +1013,61 @@ func coerceType(typ string) *graphql.Scalar {
 	return nil
 }
 
+// resolveType resolves a type name from the config to a scalar or one of the configured types, a list of either is
+// denoted by [name]. nil is returned when the type is unknown.
+func resolveType(typ string, gqlObjects map[string]graphql.Output) graphql.Output {
+	typ = strings.TrimSpace(typ)
+
+	if strings.HasPrefix(typ, "[") && strings.HasSuffix(typ, "]") {
+		if elem := resolveType(typ[1:len(typ)-1], gqlObjects); elem != nil {
+			return graphql.NewList(elem)
+		}
+		return nil
+	}
+
+	if scalar := coerceType(typ); scalar != nil {
+		return scalar
+	}
+
+	if obj, ok := gqlObjects[typ]; ok {
+		return obj
+	}
+
+	return nil
+}
+
+// resolveInputType resolves a type name from the config to a scalar or one of the configured enums, a list of either
+// is denoted by [name]. nil is returned when the type is unknown or can't be used as an input.
+func resolveInputType(typ string, gqlObjects map[string]graphql.Output) graphql.Input {
+	typ = strings.TrimSpace(typ)
+
+	if strings.HasPrefix(typ, "[") && strings.HasSuffix(typ, "]") {
+		if elem := resolveInputType(typ[1:len(typ)-1], gqlObjects); elem != nil {
+			return graphql.NewList(elem)
+		}
+		return nil
+	}
+
+	if scalar := coerceType(typ); scalar != nil {
+		return scalar
+	}
+
+	if enum, ok := gqlObjects[typ].(*graphql.Enum); ok {
+		return enum
+	}
+
+	return nil
+}
+
+// replyValue returns the value of a reply attribute, or nil if the handler didn't reply with it
+func replyValue(results map[string]*data.Attribute, name string) interface{} {
+	if attr, ok := results[name]; ok && attr != nil {
+		return attr.Value()
+	}
+
+	return nil
+}
+
 func lower(f interface{}) interface{} {
 	switch f := f.(type) {
 	case []interface{}:
@@ -0,0 +1,334 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/TIBCOSoftware/flogo-lib/core/trigger"
+)
+
+// testTypes & testSchema serve a user type from the user field
+const (
+	testTypes  = `[{"Name":"user","Fields":{"id":{"Type":"graphql.String"},"name":{"Type":"graphql.String"}}}]`
+	testSchema = `{"Query":{"Name":"Query","Fields":{"user":{"Type":"user","Args":{"id":{"Type":"graphql.String"}}}}}}`
+)
+
+// siblingTypes & siblingSchema serve the user & address fields, each resolved by its own handler
+const (
+	siblingTypes  = `[{"Name":"user","Fields":{"name":{"Type":"graphql.String"}}},{"Name":"address","Fields":{"city":{"Type":"graphql.String"}}}]`
+	siblingSchema = `{"Query":{"Name":"Query","Fields":{"user":{"Type":"user"},"address":{"Type":"address"}}}}`
+)
+
+// replyFunc computes the reply attributes of a test handler from its trigger data, an error fails the handler
+type replyFunc func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error)
+
+// replyData replies with the data
+func replyData(value interface{}) replyFunc {
+	return func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"data": value}, nil
+	}
+}
+
+// replyWith replies with the attributes, such as status & error
+func replyWith(attrs map[string]interface{}) replyFunc {
+	return func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+		return attrs, nil
+	}
+}
+
+// testHandler is a handler replying without running a flow
+type testHandler struct {
+	settings map[string]interface{}
+	reply    replyFunc
+}
+
+// newTestHandler returns a handler resolving the field with the reply
+func newTestHandler(field string, reply replyFunc) *trigger.Handler {
+	return newTestHandlerWith(map[string]interface{}{"resolverFor": field}, reply)
+}
+
+// newTestHandlerWith returns a handler with the settings, such as resolverFor & tenant, replying with the reply
+func newTestHandlerWith(settings map[string]interface{}, reply replyFunc) *trigger.Handler {
+	return trigger.NewHandlerAlt(&testHandler{settings: settings, reply: reply})
+}
+
+func (h *testHandler) Handle(ctx context.Context, triggerData map[string]interface{}) (map[string]*data.Attribute, error) {
+	reply, err := h.reply(ctx, triggerData)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]*data.Attribute, len(reply))
+	for name, value := range reply {
+		if attrs[name], err = data.NewAttribute(name, data.TypeAny, value); err != nil {
+			return nil, err
+		}
+	}
+	return attrs, nil
+}
+
+func (h *testHandler) GetSetting(setting string) (interface{}, bool) {
+	val, ok := h.settings[setting]
+	return val, ok
+}
+
+func (h *testHandler) GetOutput() map[string]interface{} {
+	return nil
+}
+
+func (h *testHandler) GetStringSetting(setting string) string {
+	val, _ := data.CoerceToString(h.settings[setting])
+	return val
+}
+
+func (h *testHandler) String() string {
+	return fmt.Sprintf("Test handler for field '%s'", h.GetStringSetting("resolverFor"))
+}
+
+type testInitContext struct {
+	handlers []*trigger.Handler
+}
+
+func (c *testInitContext) GetHandlers() []*trigger.Handler {
+	return c.handlers
+}
+
+// testSettings returns the settings of a trigger serving the types & schema on /graphql and a random port, along
+// with the extra settings
+func testSettings(t *testing.T, types, schema string, extra map[string]interface{}) map[string]interface{} {
+	settings := map[string]interface{}{"port": "0", "path": "/graphql", "operation": "QUERY"}
+
+	var typesSetting []interface{}
+	var schemaSetting map[string]interface{}
+	if err := json.Unmarshal([]byte(types), &typesSetting); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(schema), &schemaSetting); err != nil {
+		t.Fatal(err)
+	}
+	settings["types"] = typesSetting
+	settings["schema"] = schemaSetting
+
+	for k, v := range extra {
+		settings[k] = v
+	}
+	return settings
+}
+
+// newTestTrigger initializes a trigger with the settings & handlers, it is not started
+func newTestTrigger(t *testing.T, settings map[string]interface{}, handlers ...*trigger.Handler) *GraphQLTrigger {
+	trg := NewFactory(nil).New(&trigger.Config{Id: "test", Settings: settings}).(*GraphQLTrigger)
+	if err := trg.Initialize(&testInitContext{handlers: handlers}); err != nil {
+		t.Fatal(err)
+	}
+	return trg
+}
+
+// postQuery posts the query to the GraphQL endpoint of the trigger as JSON, accepting the media type
+func postQuery(trg *GraphQLTrigger, accept, query string) *httptest.ResponseRecorder {
+	header := http.Header{}
+	if accept != "" {
+		header.Set("Accept", accept)
+	}
+	return postQueryWith(trg, header, query)
+}
+
+// postQueryWith posts the query to the GraphQL endpoint of the trigger as JSON, with the headers
+func postQueryWith(trg *GraphQLTrigger, header http.Header, query string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]interface{}{"query": query})
+
+	r := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Content-Type", mediaTypeJSON)
+
+	w := httptest.NewRecorder()
+	trg.ServeHTTP(w, r)
+	return w
+}
+
+// decodeResult decodes the JSON result of a response
+func decodeResult(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	var result map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("invalid response %d: %s", w.Code, w.Body)
+	}
+	return result
+}
+
+func TestResponseStatus(t *testing.T) {
+	trg := newTestTrigger(t, testSettings(t, testTypes, testSchema, nil), newTestHandler("user", replyData(map[string]interface{}{"name": "Matt"})))
+
+	tests := []struct {
+		accept string
+		query  string
+		valid  bool
+		status int
+	}{
+		{mediaTypeJSON, `{ user(id: "1") { name } }`, true, http.StatusOK},
+		{mediaTypeGraphQLResponse, `{ user(id: "1") { name } }`, true, http.StatusOK},
+
+		// Request errors are only 4xx for application/graphql-response+json
+		{mediaTypeJSON, `{ user(id: "1") {`, false, http.StatusOK},
+		{mediaTypeGraphQLResponse, `{ user(id: "1") {`, false, http.StatusBadRequest},
+		{mediaTypeJSON, `{ account { name } }`, false, http.StatusOK},
+		{mediaTypeGraphQLResponse, `{ account { name } }`, false, http.StatusBadRequest},
+		{"", `{ account { name } }`, false, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		w := postQuery(trg, test.accept, test.query)
+		if w.Code != test.status {
+			t.Errorf("Accept '%s', query %s: got status %d, want %d: %s", test.accept, test.query, w.Code, test.status, w.Body)
+		}
+
+		result := decodeResult(t, w)
+		if test.valid && (result["data"] == nil || result["errors"] != nil) {
+			t.Errorf("Accept '%s', query %s: expected data, got %s", test.accept, test.query, w.Body)
+		}
+		if !test.valid && (result["data"] != nil || result["errors"] == nil) {
+			t.Errorf("Accept '%s', query %s: expected errors, got %s", test.accept, test.query, w.Body)
+		}
+	}
+}
+
+const (
+	abstractTypes = `[
+		{"Name":"node","Kind":"interface","Discriminator":"kind","Fields":{"id":{"Type":"graphql.String"}}},
+		{"Name":"user","Interfaces":["node"],"Fields":{"name":{"Type":"graphql.String"}}},
+		{"Name":"group","Interfaces":["node"],"Fields":{"members":{"Type":"graphql.Int"}}},
+		{"Name":"address","Fields":{"city":{"Type":"graphql.String"}}},
+		{"Name":"result","Kind":"union","Types":["user","group"]}
+	]`
+	abstractSchema = `{"Query":{"Name":"Query","Fields":{"node":{"Type":"node"},"search":{"Type":"[result]"}}}}`
+)
+
+func TestAbstractTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		reply interface{}
+		query string
+		want  string
+		fails bool
+	}{
+		{
+			"interface by __typename", "node",
+			map[string]interface{}{"__typename": "user", "id": "1", "name": "Matt"},
+			`{ node { __typename id ... on user { name } } }`,
+			`{"node":{"__typename":"user","id":"1","name":"Matt"}}`, false,
+		},
+		{
+			"interface by discriminator", "node",
+			map[string]interface{}{"kind": "group", "id": "2", "members": 3},
+			`{ node { __typename id ... on group { members } } }`,
+			`{"node":{"__typename":"group","id":"2","members":3}}`, false,
+		},
+		{
+			"union by __typename", "search",
+			[]interface{}{map[string]interface{}{"__typename": "user", "name": "Matt"}, map[string]interface{}{"__typename": "group", "members": 3}},
+			`{ search { __typename ... on user { name } ... on group { members } } }`,
+			`{"search":[{"__typename":"user","name":"Matt"},{"__typename":"group","members":3}]}`, false,
+		},
+		{
+			"union without a discriminator", "search",
+			[]interface{}{map[string]interface{}{"kind": "user", "name": "Matt"}},
+			`{ search { __typename } }`,
+			`{"search":[null]}`, true,
+		},
+		{
+			"unknown type", "node",
+			map[string]interface{}{"__typename": "account", "id": "3"},
+			`{ node { id } }`,
+			`{"node":null}`, true,
+		},
+		{
+			"type out of the union", "search",
+			[]interface{}{map[string]interface{}{"__typename": "address", "city": "Paris"}},
+			`{ search { __typename } }`,
+			`{"search":[null]}`, true,
+		},
+	}
+
+	for _, test := range tests {
+		trg := newTestTrigger(t, testSettings(t, abstractTypes, abstractSchema, nil), newTestHandler(test.field, replyData(test.reply)))
+
+		result := decodeResult(t, postQuery(trg, "", test.query))
+		data, _ := json.Marshal(result["data"])
+		if string(data) != test.want {
+			t.Errorf("%s: got data %s, want %s", test.name, data, test.want)
+		}
+
+		if errs, _ := result["errors"].([]interface{}); (len(errs) == 1) != test.fails {
+			t.Errorf("%s: unexpected errors %v", test.name, result["errors"])
+		}
+	}
+}
+
+func TestSchemaDocumentation(t *testing.T) {
+	types := `[
+		{"Name":"user","Description":"A user of the app","Fields":{
+			"name":{"Type":"graphql.String","Description":"The full name"},
+			"login":{"Type":"graphql.String","DeprecationReason":"Use name"}
+		}},
+		{"Name":"role","Kind":"enum","Values":["ADMIN",{"Name":"GUEST","DeprecationReason":"Use ADMIN"}]}
+	]`
+	schema := `{"Query":{"Name":"Query","Fields":{
+		"user":{"Type":"user","Description":"Finds a user","Args":{
+			"id":{"Type":"graphql.String","Description":"The ID of the user","DefaultValue":"me"},
+			"limit":{"Type":"graphql.Int","DefaultValue":10},
+			"role":{"Type":"role","DefaultValue":"ADMIN"}
+		}},
+		"me":{"Type":"user","DeprecationReason":"Use user"}
+	}}}`
+
+	var args []interface{}
+	trg := newTestTrigger(t, testSettings(t, types, schema, nil),
+		newTestHandler("user", func(ctx context.Context, triggerData map[string]interface{}) (map[string]interface{}, error) {
+			args = append(args, triggerData["args"])
+			return map[string]interface{}{"data": map[string]interface{}{"name": "Matt"}}, nil
+		}),
+		newTestHandler("me", replyData(map[string]interface{}{"name": "Matt"})))
+
+	result := decodeResult(t, postQuery(trg, "", `{
+		user: __type(name: "user") { description fields(includeDeprecated: true) { name description isDeprecated deprecationReason } }
+		role: __type(name: "role") { enumValues(includeDeprecated: true) { name isDeprecated deprecationReason } }
+		query: __type(name: "Query") { fields(includeDeprecated: true) { name description deprecationReason args { name description defaultValue } } }
+	}`))
+
+	got, _ := json.Marshal(result["data"])
+	for _, want := range []string{
+		`"description":"A user of the app"`,
+		`{"deprecationReason":null,"description":"The full name","isDeprecated":false,"name":"name"}`,
+		`{"deprecationReason":"Use name","description":"","isDeprecated":true,"name":"login"}`,
+		`{"deprecationReason":"Use ADMIN","isDeprecated":true,"name":"GUEST"}`,
+		`"deprecationReason":"Use user","description":"","name":"me"`,
+		`"description":"Finds a user","name":"user"`,
+		`{"defaultValue":"\"me\"","description":"The ID of the user","name":"id"}`,
+		`{"defaultValue":"10","description":"","name":"limit"}`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("%s not found in %s", want, got)
+		}
+	}
+
+	// Default values are passed to handlers when the arguments are omitted
+	postQuery(trg, "", `{ user { name } }`)
+	postQuery(trg, "", `{ user(id: "1", limit: 2, role: GUEST) { name } }`)
+
+	want := []interface{}{
+		map[string]interface{}{"id": "me", "limit": 10, "role": "ADMIN"},
+		map[string]interface{}{"id": "1", "limit": 2, "role": "GUEST"},
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("got args %v, want %v", args, want)
+	}
+}
@@ -0,0 +1,195 @@
+package graphql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/TIBCOSoftware/flogo-lib/core/data"
+	"github.com/graphql-go/graphql"
+)
+
+// argConstraints holds the constraints declared on an argument definition, nil values are not checked
+type argConstraints struct {
+	min       *float64
+	max       *float64
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+	oneOf     []interface{}
+}
+
+// argumentError is the error of an argument which does not satisfy its constraints
+type argumentError struct {
+	argument string
+	message  string
+}
+
+func (e *argumentError) Error() string {
+	return fmt.Sprintf("argument '%s' %s", e.argument, e.message)
+}
+
+// Extensions names the offending argument in the GraphQL error
+func (e *argumentError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"argument": e.argument}
+}
+
+// buildArgConstraints builds the constraints of the arguments of a field from their definitions. Arguments without
+// constraints are left out.
+func buildArgConstraints(fieldName string, argDefs map[string]interface{}) (map[string]*argConstraints, error) {
+	constraints := make(map[string]*argConstraints)
+
+	for k, v := range argDefs {
+		argTyp, _ := v.(map[string]interface{})
+
+		c, err := newArgConstraints(argTyp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint for argument '%s' of field '%s': %v", k, fieldName, err)
+		}
+		if c != nil {
+			constraints[k] = c
+		}
+	}
+
+	return constraints, nil
+}
+
+func newArgConstraints(argTyp map[string]interface{}) (*argConstraints, error) {
+	c := &argConstraints{}
+	found := false
+
+	for _, name := range []string{"min", "max"} {
+		val, ok := argTyp[name]
+		if !ok {
+			continue
+		}
+		n, err := data.CoerceToDouble(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", name)
+		}
+		if name == "min" {
+			c.min = &n
+		} else {
+			c.max = &n
+		}
+		found = true
+	}
+
+	for _, name := range []string{"minlength", "maxlength"} {
+		val, ok := argTyp[name]
+		if !ok {
+			continue
+		}
+		n, err := data.CoerceToInteger(val)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", name)
+		}
+		if name == "minlength" {
+			c.minLength = &n
+		} else {
+			c.maxLength = &n
+		}
+		found = true
+	}
+
+	if val, ok := argTyp["pattern"]; ok {
+		pattern, _ := val.(string)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern '%v' is not a valid regular expression", val)
+		}
+		c.pattern = re
+		found = true
+	}
+
+	if val, ok := argTyp["oneof"]; ok {
+		values, ok := val.([]interface{})
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("oneOf must be a non-empty list of values")
+		}
+		c.oneOf = values
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return c, nil
+}
+
+// check checks a value against the constraints, the constraints apply to each item of a list
+func (c *argConstraints) check(name string, value interface{}) error {
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if err := c.check(name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case int, float64:
+		n, _ := data.CoerceToDouble(v)
+		if c.min != nil && n < *c.min {
+			return &argumentError{argument: name, message: fmt.Sprintf("must be at least %v", *c.min)}
+		}
+		if c.max != nil && n > *c.max {
+			return &argumentError{argument: name, message: fmt.Sprintf("must be at most %v", *c.max)}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if c.minLength != nil && length < *c.minLength {
+			return &argumentError{argument: name, message: fmt.Sprintf("must be at least %d characters long", *c.minLength)}
+		}
+		if c.maxLength != nil && length > *c.maxLength {
+			return &argumentError{argument: name, message: fmt.Sprintf("must be at most %d characters long", *c.maxLength)}
+		}
+		if c.pattern != nil && !c.pattern.MatchString(v) {
+			return &argumentError{argument: name, message: fmt.Sprintf("must match the pattern '%s'", c.pattern)}
+		}
+	}
+
+	if len(c.oneOf) > 0 {
+		// Values are compared by their text, as numbers from the config are floats while Int arguments are ints
+		for _, allowed := range c.oneOf {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				return nil
+			}
+		}
+		return &argumentError{argument: name, message: fmt.Sprintf("must be one of %v", c.oneOf)}
+	}
+
+	return nil
+}
+
+// validatingResolver checks the arguments of the field against their constraints before resolving it
+func validatingResolver(constraints map[string]*argConstraints, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	if len(constraints) == 0 {
+		return resolve
+	}
+
+	// Arguments are checked in a stable order, so that the same error is reported for the same query
+	names := make([]string, 0, len(constraints))
+	for name := range constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		for _, name := range names {
+			if err := constraints[name].check(name, p.Args[name]); err != nil {
+				return nil, err
+			}
+		}
+
+		return resolve(p)
+	}
+}
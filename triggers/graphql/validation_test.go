@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestArgConstraints(t *testing.T) {
+	tests := []struct {
+		name   string
+		argTyp map[string]interface{}
+		value  interface{}
+		valid  bool
+	}{
+		{"min", map[string]interface{}{"min": 1}, 1, true},
+		{"below min", map[string]interface{}{"min": 1}, 0, false},
+		{"max", map[string]interface{}{"max": 10.5}, 10.5, true},
+		{"above max", map[string]interface{}{"max": 10}, 11, false},
+		{"float below min", map[string]interface{}{"min": "0.5"}, 0.25, false},
+		{"minlength", map[string]interface{}{"minlength": 2}, "ab", true},
+		{"below minlength", map[string]interface{}{"minlength": 2}, "a", false},
+		{"maxlength counts runes", map[string]interface{}{"maxlength": 3}, "héé", true},
+		{"above maxlength", map[string]interface{}{"maxlength": 3}, "abcd", false},
+		{"pattern", map[string]interface{}{"pattern": "^[a-z]+$"}, "abc", true},
+		{"pattern mismatch", map[string]interface{}{"pattern": "^[a-z]+$"}, "ABC", false},
+		{"oneof", map[string]interface{}{"oneof": []interface{}{"red", "green"}}, "green", true},
+		{"not oneof", map[string]interface{}{"oneof": []interface{}{"red", "green"}}, "blue", false},
+		{"oneof int against float", map[string]interface{}{"oneof": []interface{}{1.0, 2.0}}, 2, true},
+		{"list", map[string]interface{}{"min": 0}, []interface{}{1, 2}, true},
+		{"list item", map[string]interface{}{"min": 0}, []interface{}{1, -2}, false},
+		{"nil", map[string]interface{}{"min": 1, "oneof": []interface{}{"a"}}, nil, true},
+		{"length ignored for numbers", map[string]interface{}{"maxlength": 1}, 12345, true},
+		{"range ignored for strings", map[string]interface{}{"max": 1}, "12345", true},
+	}
+
+	for _, test := range tests {
+		c, err := newArgConstraints(test.argTyp)
+		if err != nil || c == nil {
+			t.Errorf("%s: got constraints %v, %v", test.name, c, err)
+			continue
+		}
+
+		err = c.check("arg", test.value)
+		if test.valid {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+
+		if argErr, ok := err.(*argumentError); !ok || argErr.argument != "arg" {
+			t.Errorf("%s: expected an argument error, got %v", test.name, err)
+		}
+	}
+}
+
+func TestNewArgConstraints(t *testing.T) {
+	if c, err := newArgConstraints(map[string]interface{}{"type": "String"}); c != nil || err != nil {
+		t.Errorf("expected no constraints, got %v, %v", c, err)
+	}
+
+	invalid := []map[string]interface{}{
+		{"min": "one"},
+		{"max": []interface{}{1}},
+		{"minlength": -1},
+		{"maxlength": "long"},
+		{"pattern": "("},
+		{"oneof": "red"},
+		{"oneof": []interface{}{}},
+	}
+	for _, argTyp := range invalid {
+		if _, err := newArgConstraints(argTyp); err == nil {
+			t.Errorf("expected an error for %v", argTyp)
+		}
+	}
+
+	if _, err := buildArgConstraints("user", map[string]interface{}{"id": map[string]interface{}{"pattern": "("}}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestValidatingResolver(t *testing.T) {
+	constraints, err := buildArgConstraints("user", map[string]interface{}{
+		"id":   map[string]interface{}{"type": "String", "minlength": 1},
+		"age":  map[string]interface{}{"type": "Int", "min": 0},
+		"name": map[string]interface{}{"type": "String"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := constraints["name"]; ok {
+		t.Error("arguments without constraints should be left out")
+	}
+
+	resolved := errors.New("resolved")
+	resolve := validatingResolver(constraints, func(p graphql.ResolveParams) (interface{}, error) {
+		return nil, resolved
+	})
+
+	if _, err := resolve(graphql.ResolveParams{Args: map[string]interface{}{"id": "1", "age": 3}}); err != resolved {
+		t.Errorf("expected the field to be resolved, got %v", err)
+	}
+
+	// Arguments are checked by name, the first failing one is reported
+	_, err = resolve(graphql.ResolveParams{Args: map[string]interface{}{"id": "", "age": -1}})
+	if argErr, ok := err.(*argumentError); !ok || argErr.argument != "age" {
+		t.Errorf("expected an error for argument 'age', got %v", err)
+	}
+}